@@ -0,0 +1,189 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta is subtracted from the lifetime of OAuth2 access tokens, so they are
+// refreshed before the API starts rejecting them.
+const oauth2ExpiryDelta = 30 * time.Second
+
+// Authenticator adds credentials to the requests sent to the APIs. It is called for every
+// request after rate limiting and must be safe for concurrent use.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// secretHolder is implemented by the authenticators of this package to report the values
+// that must never appear in logs.
+type secretHolder interface {
+	secrets() []string
+}
+
+// BearerToken authenticates requests with a static API token sent as bearer token. This is
+// what New does with the token it is given.
+type BearerToken string
+
+// Authenticate sets the Authorization header.
+func (t BearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// String returns the redacted token.
+func (t BearerToken) String() string {
+	return redactToken(string(t))
+}
+
+func (t BearerToken) secrets() []string {
+	return []string{string(t)}
+}
+
+// ClientIDAPIKey authenticates requests with the DB-Client-Id and DB-Api-Key headers used
+// by the DB API Marketplace.
+type ClientIDAPIKey struct {
+	ClientID string
+	APIKey   string
+}
+
+// Authenticate sets the DB-Client-Id and DB-Api-Key headers.
+func (c ClientIDAPIKey) Authenticate(req *http.Request) error {
+	req.Header.Set("DB-Client-Id", c.ClientID)
+	req.Header.Set("DB-Api-Key", c.APIKey)
+	return nil
+}
+
+// String returns the client id; the API key is not shown.
+func (c ClientIDAPIKey) String() string {
+	return "client " + c.ClientID
+}
+
+func (c ClientIDAPIKey) secrets() []string {
+	return []string{c.APIKey}
+}
+
+// OAuth2ClientCredentials authenticates requests with an access token obtained from TokenURL
+// using the OAuth2 client credentials grant. The token is cached and refreshed shortly before
+// it expires, or after the API rejected it. HTTPClient is used to request tokens and defaults
+// to http.DefaultClient. An OAuth2ClientCredentials must not be copied after first use.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	HTTPClient   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// OAuth2Error is returned if the token endpoint refuses to issue an access token.
+type OAuth2Error struct {
+	StatusCode       int
+	ErrorCode        string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e *OAuth2Error) Error() string {
+	return fmt.Sprintf("oauth2: cannot fetch token: %d %s: %s", e.StatusCode, e.ErrorCode, e.ErrorDescription)
+}
+
+// Authenticate sets the Authorization header, requesting a new access token if necessary.
+func (o *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := o.token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidate drops the cached access token, so the next request fetches a new one. The Client
+// calls it when the API responds with 401 Unauthorized.
+func (o *OAuth2ClientCredentials) Invalidate() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.accessToken = ""
+}
+
+// String returns the client id; the secret and access token are not shown.
+func (o *OAuth2ClientCredentials) String() string {
+	return "oauth2 client " + o.ClientID
+}
+
+func (o *OAuth2ClientCredentials) secrets() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return []string{o.ClientSecret, o.accessToken}
+}
+
+func (o *OAuth2ClientCredentials) token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.accessToken != "" && (o.expiry.IsZero() || time.Now().Before(o.expiry)) {
+		return o.accessToken, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	httpClient := o.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauth2Error := &OAuth2Error{StatusCode: resp.StatusCode}
+		json.Unmarshal(body, oauth2Error)
+		return "", oauth2Error
+	}
+
+	tokenResponse := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", &OAuth2Error{StatusCode: resp.StatusCode, ErrorCode: "invalid_response", ErrorDescription: "no access_token in response"}
+	}
+
+	o.accessToken = tokenResponse.AccessToken
+	o.expiry = time.Time{}
+	if tokenResponse.ExpiresIn > 0 {
+		o.expiry = time.Now().Add(time.Duration(tokenResponse.ExpiresIn)*time.Second - oauth2ExpiryDelta)
+	}
+	return o.accessToken, nil
+}
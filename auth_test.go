@@ -0,0 +1,127 @@
+package dbapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIDAPIKey(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var header http.Header
+	c := New("", Config{
+		Authenticator: ClientIDAPIKey{ClientID: "my-client", APIKey: "SecretKey"},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				header = req.Header.Clone()
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+
+	_, err := c.StationDataAPI().StationByID(1)
+	assert.Nil(err)
+	assert.Equal("my-client", header.Get("DB-Client-Id"))
+	assert.Equal("SecretKey", header.Get("DB-Api-Key"))
+	assert.Empty(header.Get("Authorization"))
+	assert.Equal("client my-client", c.KeyUsage()[0].Key)
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	assert := assert.New(t)
+
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+
+		clientID, secret, _ := request.BasicAuth()
+		if clientID != "my-client" || secret != "my-secret" || request.FormValue("grant_type") != "client_credentials" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(writer, `{"error":"invalid_client","error_description":"Bad client credentials"}`)
+			return
+		}
+		assert.Equal("stations facilities", request.FormValue("scope"))
+
+		writer.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(writer, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	var authorizations []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorizations = append(authorizations, request.Header.Get("Authorization"))
+		if len(authorizations) == 2 {
+			writer.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(writer, "Token revoked")
+			return
+		}
+		fmt.Fprint(writer, `{"total":1,"result":[{"number":1,"name":"Aachen Hbf"}]}`)
+	}))
+	defer apiServer.Close()
+	APIURL = apiServer.URL
+
+	c := New("", Config{
+		Authenticator: &OAuth2ClientCredentials{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "my-client",
+			ClientSecret: "my-secret",
+			Scopes:       []string{"stations", "facilities"},
+		},
+	})
+	s := c.StationDataAPI()
+
+	_, err := s.StationByID(1)
+	assert.Nil(err)
+	// The revoked token is replaced and the request retried
+	_, err = s.StationByID(1)
+	assert.Nil(err)
+	_, err = s.StationByID(1)
+	assert.Nil(err)
+
+	assert.Equal([]string{"Bearer token-1", "Bearer token-1", "Bearer token-2", "Bearer token-2"}, authorizations)
+	assert.Equal(int32(2), atomic.LoadInt32(&tokenRequests))
+	assert.Equal(int64(4), c.KeyUsage()[0].Requests)
+	assert.Equal(int64(1), c.KeyUsage()[0].Rejected)
+
+	c = New("", Config{
+		Authenticator: &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "unknown"},
+	})
+	_, err = c.StationDataAPI().StationByID(1)
+	assert.Equal(&OAuth2Error{StatusCode: 401, ErrorCode: "invalid_client", ErrorDescription: "Bad client credentials"}, err)
+}
+
+func TestOAuth2ClientCredentials_RetryOnce(t *testing.T) {
+	assert := assert.New(t)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(writer, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	var requests int32
+	apiServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		writer.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(writer, "Token revoked")
+	}))
+	defer apiServer.Close()
+	APIURL = apiServer.URL
+
+	c := New("", Config{
+		Authenticator: &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "my-client"},
+	})
+
+	_, err := c.StationDataAPI().StationByID(1)
+	assert.EqualError(err, "Token revoked")
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,289 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const betriebsstellenAPIPath = "/betriebsstellen/v1"
+
+// betriebsstellenAPIName identifies the Betriebsstellen API in instrumentation events.
+const betriebsstellenAPIName = "betriebsstellen"
+
+// betriebsstelleDateLayout is the layout of the validity dates sent by the Betriebsstellen API.
+const betriebsstelleDateLayout = "2006-01-02"
+
+// Endpoint templates of the Betriebsstellen API.
+const (
+	BetriebsstellenEndpoint = betriebsstellenAPIPath + "/betriebsstellen"
+	BetriebsstelleEndpoint  = betriebsstellenAPIPath + "/betriebsstellen/{abbrev}"
+)
+
+// BetriebsstellenConfig provides configuration options for the Betriebsstellen API. Set
+// RateLimitPerMinute to zero if you want to disable rate limiting done in the library.
+type BetriebsstellenConfig struct {
+	RateLimitPerMinute int
+}
+
+// BetriebsstelleType is the type of a Betriebsstelle.
+type BetriebsstelleType string
+
+// Common types of operating points. The API knows further types, e.g. for sidings.
+const (
+	BetriebsstelleStation     BetriebsstelleType = "Bf"
+	BetriebsstelleStationPart BetriebsstelleType = "Bft"
+	BetriebsstelleHalt        BetriebsstelleType = "Hp"
+	BetriebsstelleJunction    BetriebsstelleType = "Abzw"
+	BetriebsstelleBlockPost   BetriebsstelleType = "Bk"
+	BetriebsstelleCrossover   BetriebsstelleType = "Üst"
+)
+
+// BetriebsstelleDate is a day in Europe/Berlin as sent by the Betriebsstellen API. The zero
+// value means the date was not set.
+type BetriebsstelleDate struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *BetriebsstelleDate) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.ParseInLocation(betriebsstelleDateLayout, *value, berlin)
+	if err != nil {
+		return err
+	}
+	d.Time = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d BetriebsstelleDate) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.In(berlin).Format(betriebsstelleDateLayout))
+}
+
+// Betriebsstelle is an operating point of the railway network, e.g. a station, a junction or a
+// block post. Abbrev is its DS100 (RIL100) abbreviation, which is the
+// Ril100Identifiers.RilIdentifier of the StationData API for stations.
+type Betriebsstelle struct {
+	Abbrev    string             `json:"abbrev"`
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	ShortName string             `json:"short"`
+	Type      BetriebsstelleType `json:"type"`
+	// Status is the operating status, e.g. "in Betrieb", empty if the API does not know it.
+	Status       string `json:"status"`
+	LocationCode string `json:"locationCode"`
+	UIC          string `json:"UIC"`
+	// Region is the number of the Regionalbereich the operating point belongs to.
+	Region int `json:"RB"`
+	// ValidFrom and ValidTill limit the validity of the operating point, ValidTill is zero if
+	// it is valid indefinitely.
+	ValidFrom         BetriebsstelleDate `json:"validFrom"`
+	ValidTill         BetriebsstelleDate `json:"validTill"`
+	TimeTableRelevant bool               `json:"timeTableRelevant"`
+	BorderStation     bool               `json:"borderStation"`
+}
+
+// ValidAt reports whether the operating point is valid on the day of t.
+func (b *Betriebsstelle) ValidAt(t time.Time) bool {
+	year, month, day := t.In(berlin).Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, berlin)
+	if !b.ValidFrom.IsZero() && date.Before(b.ValidFrom.Time) {
+		return false
+	}
+	return b.ValidTill.IsZero() || !date.After(b.ValidTill.Time)
+}
+
+// Station returns the StationData station with the abbreviation of the operating point as
+// RIL100 identifier, nil if there is none, e.g. for junctions and block posts.
+func (b *Betriebsstelle) Station(ctx context.Context, stations StationDataService) (*Station, error) {
+	resp, err := stations.StationByFilterContext(ctx, StationDataStationRequest{Ril: b.Abbrev})
+	var notFound *StationDataErrorResponse
+	if errors.As(err, &notFound) && notFound.ErrNo == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range resp.Result {
+		for _, ril := range resp.Result[i].Ril100Identifiers {
+			if strings.EqualFold(ril.RilIdentifier, b.Abbrev) {
+				return &resp.Result[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// BetriebsstellenAPI is a struct holding internal information about this API. Its methods can
+// be used to query the API.
+type BetriebsstellenAPI struct {
+	client *Client
+}
+
+// All returns all operating points. Use NewBetriebsstellenIndex to look them up offline.
+func (b *BetriebsstellenAPI) All() ([]Betriebsstelle, error) {
+	return b.AllContext(context.Background())
+}
+
+// AllContext is like All but aborts waiting for the rate limiter and the request once ctx is
+// done.
+func (b *BetriebsstellenAPI) AllContext(ctx context.Context) ([]Betriebsstelle, error) {
+	var betriebsstellen []Betriebsstelle
+	err := b.get(ctx, &call{
+		operation: "All",
+		endpoint:  BetriebsstellenEndpoint,
+		url:       fmt.Sprintf("%s%s/betriebsstellen", b.client.baseURL(), betriebsstellenAPIPath),
+	}, &betriebsstellen)
+	return betriebsstellen, err
+}
+
+// ByName returns the operating points whose name contains name.
+func (b *BetriebsstellenAPI) ByName(name string) ([]Betriebsstelle, error) {
+	return b.ByNameContext(context.Background(), name)
+}
+
+// ByNameContext is like ByName but aborts waiting for the rate limiter and the request once
+// ctx is done.
+func (b *BetriebsstellenAPI) ByNameContext(ctx context.Context, name string) ([]Betriebsstelle, error) {
+	q := url.Values{"name": []string{name}}
+
+	var betriebsstellen []Betriebsstelle
+	err := b.get(ctx, &call{
+		operation:  "ByName",
+		endpoint:   BetriebsstellenEndpoint,
+		url:        fmt.Sprintf("%s%s/betriebsstellen?%s", b.client.baseURL(), betriebsstellenAPIPath, q.Encode()),
+		attributes: filterAttributes(q),
+	}, &betriebsstellen)
+	return betriebsstellen, err
+}
+
+// ByAbbrev returns the operating point with the given DS100 abbreviation, e.g. "FF".
+func (b *BetriebsstellenAPI) ByAbbrev(abbrev string) (*Betriebsstelle, error) {
+	return b.ByAbbrevContext(context.Background(), abbrev)
+}
+
+// ByAbbrevContext is like ByAbbrev but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (b *BetriebsstellenAPI) ByAbbrevContext(ctx context.Context, abbrev string) (*Betriebsstelle, error) {
+	betriebsstelle := &Betriebsstelle{}
+	err := b.get(ctx, &call{
+		operation:  "ByAbbrev",
+		endpoint:   BetriebsstelleEndpoint,
+		url:        fmt.Sprintf("%s%s/betriebsstellen/%s", b.client.baseURL(), betriebsstellenAPIPath, url.PathEscape(abbrev)),
+		attributes: []attribute.KeyValue{attribute.String("dbapi.betriebsstelle.abbrev", abbrev)},
+	}, betriebsstelle)
+	return betriebsstelle, err
+}
+
+func (b *BetriebsstellenAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = betriebsstellenAPIName
+	c.rateLimitPerMinute = b.client.apiConfig.BetriebsstellenConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := b.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(betriebsstellenAPIName, resp, data, json.Unmarshal)
+}
+
+// BetriebsstellenIndex looks up operating points offline, e.g. from the result of
+// BetriebsstellenAPI.All saved to a file. It is safe for concurrent use as it is never
+// modified after creation.
+type BetriebsstellenIndex struct {
+	all      []Betriebsstelle
+	byAbbrev map[string]int
+}
+
+// NewBetriebsstellenIndex creates an index of the given operating points.
+func NewBetriebsstellenIndex(betriebsstellen []Betriebsstelle) *BetriebsstellenIndex {
+	all := append([]Betriebsstelle(nil), betriebsstellen...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Abbrev < all[j].Abbrev
+	})
+
+	index := &BetriebsstellenIndex{all: all, byAbbrev: make(map[string]int, len(all))}
+	for i, betriebsstelle := range all {
+		index.byAbbrev[normalizeAbbrev(betriebsstelle.Abbrev)] = i
+	}
+	return index
+}
+
+// LoadBetriebsstellenIndex reads an index written by BetriebsstellenIndex.Save. The file
+// format is the JSON array sent by the API, so a response saved by other means can be read as
+// well.
+func LoadBetriebsstellenIndex(path string) (*BetriebsstellenIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var betriebsstellen []Betriebsstelle
+	if err := json.Unmarshal(data, &betriebsstellen); err != nil {
+		return nil, fmt.Errorf("dbapi: invalid betriebsstellen file %s: %w", path, err)
+	}
+	return NewBetriebsstellenIndex(betriebsstellen), nil
+}
+
+// Save writes all operating points of the index to path.
+func (i *BetriebsstellenIndex) Save(path string) error {
+	data, err := json.MarshalIndent(i.all, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// All returns all operating points sorted by abbreviation.
+func (i *BetriebsstellenIndex) All() []Betriebsstelle {
+	return append([]Betriebsstelle(nil), i.all...)
+}
+
+// ByAbbrev returns the operating point with the given DS100 abbreviation. Case and surplus
+// spaces are ignored.
+func (i *BetriebsstellenIndex) ByAbbrev(abbrev string) (Betriebsstelle, bool) {
+	index, ok := i.byAbbrev[normalizeAbbrev(abbrev)]
+	if !ok {
+		return Betriebsstelle{}, false
+	}
+	return i.all[index], true
+}
+
+// ByName returns the operating points whose name or short name contains name, ignoring case.
+func (i *BetriebsstellenIndex) ByName(name string) []Betriebsstelle {
+	name = strings.ToLower(name)
+
+	var result []Betriebsstelle
+	for _, betriebsstelle := range i.all {
+		if strings.Contains(strings.ToLower(betriebsstelle.Name), name) ||
+			strings.Contains(strings.ToLower(betriebsstelle.ShortName), name) {
+			result = append(result, betriebsstelle)
+		}
+	}
+	return result
+}
+
+// normalizeAbbrev makes DS100 abbreviations comparable, which may contain spaces, e.g. "FF G".
+func normalizeAbbrev(abbrev string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(abbrev), " "))
+}
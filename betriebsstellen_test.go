@@ -0,0 +1,144 @@
+package dbapi
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/betriebsstellen/v1/", ".json", `{"code":404,"message":"Not Found"}`)
+}
+
+func TestBetriebsstellenAPI_ByAbbrev(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	b := c.BetriebsstellenAPI()
+
+	ff, err := b.ByAbbrev("FF")
+	assert.Nil(err)
+	assert.Equal(Betriebsstelle{
+		Abbrev:            "FF",
+		ID:                1176,
+		Name:              "Frankfurt (Main) Hbf",
+		ShortName:         "Frankfurt Hbf",
+		Type:              BetriebsstelleStation,
+		LocationCode:      "DE01866",
+		UIC:               "8000105",
+		Region:            5,
+		ValidFrom:         BetriebsstelleDate{time.Date(1997, 1, 1, 0, 0, 0, 0, berlin)},
+		TimeTableRelevant: true,
+	}, *ff)
+
+	_, err = b.ByAbbrev("XX")
+	assert.Equal(&APIError{API: "betriebsstellen", StatusCode: 404, Message: `{"code":404,"message":"Not Found"}`}, err)
+}
+
+func TestBetriebsstellenAPI_ByName(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var query string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{captureQuery(&query)},
+	})
+
+	betriebsstellen, err := c.BetriebsstellenAPI().ByName("Frankfurt (Main)")
+	assert.Nil(err)
+	assert.Equal("name=Frankfurt+%28Main%29", query)
+	assert.Len(betriebsstellen, 4)
+}
+
+func TestBetriebsstelle_ValidAt(t *testing.T) {
+	assert := assert.New(t)
+
+	b := Betriebsstelle{
+		ValidFrom: BetriebsstelleDate{time.Date(1997, 1, 1, 0, 0, 0, 0, berlin)},
+		ValidTill: BetriebsstelleDate{time.Date(2019, 12, 14, 0, 0, 0, 0, berlin)},
+	}
+
+	assert.False(b.ValidAt(time.Date(1996, 12, 31, 23, 59, 0, 0, berlin)))
+	assert.True(b.ValidAt(time.Date(1997, 1, 1, 0, 0, 0, 0, berlin)))
+	assert.True(b.ValidAt(time.Date(2019, 12, 14, 23, 30, 0, 0, berlin)))
+	// 2019-12-14 23:30 UTC is already the next day in Berlin
+	assert.False(b.ValidAt(time.Date(2019, 12, 14, 23, 30, 0, 0, time.UTC)))
+
+	b.ValidTill = BetriebsstelleDate{}
+	assert.True(b.ValidAt(time.Date(2030, 1, 1, 0, 0, 0, 0, berlin)))
+}
+
+func TestBetriebsstelle_Station(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	index := NewBetriebsstellenIndex(mustBetriebsstellen(t, c))
+
+	ka, ok := index.ByAbbrev("KA")
+	assert.True(ok)
+	station, err := ka.Station(context.Background(), c.StationDataAPI())
+	assert.Nil(err)
+	assert.Equal(1, station.Number)
+	assert.Equal("Aachen Hbf", station.Name)
+
+	junction, _ := index.ByAbbrev("FFNW")
+	station, err = junction.Station(context.Background(), c.StationDataAPI())
+	assert.Nil(err)
+	assert.Nil(station)
+}
+
+func TestBetriebsstellenIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	index := NewBetriebsstellenIndex(mustBetriebsstellen(t, c))
+
+	kaw, ok := index.ByAbbrev(" kaw ")
+	assert.True(ok)
+	assert.Equal(BetriebsstelleBlockPost, kaw.Type)
+	assert.Equal("außer Betrieb", kaw.Status)
+
+	_, ok = index.ByAbbrev("XX")
+	assert.False(ok)
+
+	frankfurt := index.ByName("frankfurt")
+	assert.Len(frankfurt, 2)
+	assert.Equal("FF", frankfurt[0].Abbrev)
+	assert.Equal("FFNW", frankfurt[1].Abbrev)
+
+	path := filepath.Join(t.TempDir(), "betriebsstellen.json")
+	assert.Nil(index.Save(path))
+
+	loaded, err := LoadBetriebsstellenIndex(path)
+	assert.Nil(err)
+	assert.Equal(index.All(), loaded.All())
+
+	_, err = LoadBetriebsstellenIndex(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(err)
+}
+
+func mustBetriebsstellen(t *testing.T, c *Client) []Betriebsstelle {
+	betriebsstellen, err := c.BetriebsstellenAPI().All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return betriebsstellen
+}
@@ -0,0 +1,175 @@
+package dbapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("dbapi: circuit breaker is open")
+
+const defaultCircuitCoolDown = 30 * time.Second
+
+// CircuitState is the state of the circuit breaker of a Client.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests pass. It is also reported if no breaker is configured.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests with ErrCircuitOpen until the cool-down has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial requests pass to probe whether the API recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig enables a circuit breaker in front of the DB API gateway. Once
+// FailureThreshold consecutive requests failed with a network error, a timeout or a 5xx status,
+// the circuit opens and requests fail fast with ErrCircuitOpen. After CoolDown (default 30s)
+// up to HalfOpenRequests (default 1) trial requests are let through; if they all succeed the
+// circuit closes again, otherwise it reopens. A FailureThreshold of zero disables the breaker.
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	CoolDown         time.Duration
+	HalfOpenRequests int
+}
+
+type circuitBreaker struct {
+	mu                  sync.Mutex
+	failureThreshold    int
+	coolDown            time.Duration
+	halfOpenRequests    int
+	state               CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+	trials              int
+	trialSuccesses      int
+	now                 func() time.Time
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		return nil
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = defaultCircuitCoolDown
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &circuitBreaker{
+		failureThreshold: cfg.FailureThreshold,
+		coolDown:         cfg.CoolDown,
+		halfOpenRequests: cfg.HalfOpenRequests,
+		now:              time.Now,
+	}
+}
+
+// allow reports whether a request may be sent. Every allowed request must be followed by
+// a call to done.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.coolDown {
+		b.state = CircuitHalfOpen
+		b.trials = 0
+		b.trialSuccesses = 0
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.trials >= b.halfOpenRequests {
+			return ErrCircuitOpen
+		}
+		b.trials++
+	}
+	return nil
+}
+
+// done records the outcome of an allowed request. Requests that neither succeeded nor
+// failed because of the API, e.g. canceled by the caller, only release their trial slot.
+func (b *circuitBreaker) done(ctx context.Context, resp *response, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		if b.state == CircuitHalfOpen {
+			b.trials--
+		}
+	case err != nil || resp.statusCode >= 500:
+		b.consecutiveFailures++
+		if b.state == CircuitHalfOpen || b.consecutiveFailures >= b.failureThreshold {
+			b.state = CircuitOpen
+			b.openedAt = b.now()
+		}
+	default:
+		b.consecutiveFailures = 0
+		if b.state == CircuitHalfOpen {
+			b.trialSuccesses++
+			if b.trialSuccesses >= b.halfOpenRequests {
+				b.state = CircuitClosed
+			}
+		}
+	}
+}
+
+// release returns the trial slot of an allowed request that was never sent to the API, e.g.
+// because the Authenticator failed, without counting it as success or failure.
+func (b *circuitBreaker) release() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.trials--
+	}
+}
+
+func (b *circuitBreaker) currentState() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.coolDown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// CircuitState returns the state of the circuit breaker, e.g. for health checks. It is always
+// CircuitClosed if no breaker is configured.
+func (client *Client) CircuitState() CircuitState {
+	return client.breaker.currentState()
+}
@@ -0,0 +1,142 @@
+package dbapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute, HalfOpenRequests: 1})
+	b.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	failure := &response{statusCode: 503}
+	success := &response{statusCode: 200}
+
+	assert.Nil(b.allow())
+	b.done(ctx, failure, nil)
+	assert.Nil(b.allow())
+	b.done(ctx, &response{statusCode: 429}, nil)
+	assert.Nil(b.allow())
+	b.done(ctx, nil, errors.New("timeout"))
+	assert.Nil(b.allow())
+	b.done(ctx, failure, nil)
+	assert.Equal(CircuitOpen, b.currentState())
+	assert.Equal(ErrCircuitOpen, b.allow())
+
+	now = now.Add(time.Minute)
+	assert.Equal(CircuitHalfOpen, b.currentState())
+	assert.Nil(b.allow())
+	assert.Equal(ErrCircuitOpen, b.allow(), "only one trial request in half-open state")
+	b.done(ctx, failure, nil)
+	assert.Equal(CircuitOpen, b.currentState())
+
+	now = now.Add(time.Minute)
+	assert.Nil(b.allow())
+	b.done(ctx, success, nil)
+	assert.Equal(CircuitClosed, b.currentState())
+}
+
+func TestCircuitBreaker_CanceledTrial(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1})
+	b.now = func() time.Time { return now }
+
+	assert.Nil(b.allow())
+	b.done(context.Background(), &response{statusCode: 500}, nil)
+	now = now.Add(defaultCircuitCoolDown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Nil(b.allow())
+	b.done(ctx, nil, context.Canceled)
+
+	assert.Equal(CircuitHalfOpen, b.currentState())
+	assert.Nil(b.allow())
+}
+
+func TestCircuitBreaker_ReleasedTrial(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1})
+	b.now = func() time.Time { return now }
+
+	assert.Nil(b.allow())
+	b.done(context.Background(), &response{statusCode: 500}, nil)
+	now = now.Add(defaultCircuitCoolDown)
+
+	assert.Nil(b.allow())
+	b.release()
+
+	assert.Equal(CircuitHalfOpen, b.currentState())
+	assert.Nil(b.allow())
+}
+
+func TestCircuitBreaker_AuthenticatorError(t *testing.T) {
+	assert := assert.New(t)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer tokenServer.Close()
+
+	c := New("", Config{
+		Authenticator:  &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "my-client"},
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 1},
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := c.StationDataAPI().StationByID(1)
+		assert.IsType(&OAuth2Error{}, err)
+	}
+	assert.Equal(CircuitClosed, c.CircuitState())
+}
+
+func TestCircuitBreaker_Client(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	calls := 0
+	c := New("SomeFakeToken", Config{
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 3},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return &http.Response{
+					StatusCode: http.StatusBadGateway,
+					Body:       io.NopCloser(strings.NewReader("Bad Gateway")),
+					Request:    req,
+				}, nil
+			})
+		}},
+	})
+	s := c.StationDataAPI()
+
+	assert.Equal(CircuitClosed, c.CircuitState())
+	for i := 0; i < 5; i++ {
+		s.StationByID(1)
+	}
+
+	_, err := s.StationByID(1)
+	assert.Equal(ErrCircuitOpen, err)
+	assert.Equal(3, calls)
+	assert.Equal(CircuitOpen, c.CircuitState())
+	assert.Equal(CircuitClosed, New("SomeFakeToken", Config{}).CircuitState())
+}
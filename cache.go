@@ -0,0 +1,322 @@
+package dbapi
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a storage backend for API responses. Entries are keyed by the request URL.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// CacheEntry is a cached API response together with the validators needed to revalidate it
+// once it expired. Entries must not be modified after they have been handed to a Cache.
+type CacheEntry struct {
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	StoredAt     time.Time `json:"storedAt"`
+	Expires      time.Time `json:"expires"`
+}
+
+// CacheConfig enables caching of API responses. Caching is disabled if no Backend is set.
+//
+// The lifetime of a response is taken from its Cache-Control max-age directive. If the
+// response does not specify one, the TTL configured for the endpoint in EndpointTTLs is
+// used, falling back to DefaultTTL. Responses marked with no-store are never cached.
+// Expired entries carrying an ETag or Last-Modified header are revalidated with a
+// conditional request, which still counts against the rate limit of the API.
+//
+// With ServeStale set, the last known good response is kept in the cache after it expired
+// and served if the API fails with a 5xx status, rate-limits the request, times out or the
+// circuit breaker is open. Such responses are flagged as Stale along with their Age. Stale
+// responses older than MaxStaleAge are not served, zero means no limit. Serving a stale
+// response triggers a refresh in the background, at most once per StaleRefreshInterval
+// (default 30s) per URL, so the cache is updated as soon as the API recovers.
+//
+// Stale responses are only served for results that carry the Stale flag, e.g. not for the
+// plain lists returned by some APIs. Live data, e.g. the changes of the Timetables API, the
+// FaSta API and parking occupancies, is never cached.
+type CacheConfig struct {
+	Backend      Cache
+	DefaultTTL   time.Duration
+	EndpointTTLs map[string]time.Duration
+
+	ServeStale           bool
+	MaxStaleAge          time.Duration
+	StaleRefreshInterval time.Duration
+}
+
+func (e *CacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+func (e *CacheEntry) revalidatable() bool {
+	return e.ETag != "" || e.LastModified != ""
+}
+
+// ttl returns how long a response with the given header may be served from the cache
+// and whether it may be stored at all.
+func (c CacheConfig) ttl(endpoint string, header http.Header) (time.Duration, bool) {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return 0, true
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	if ttl, ok := c.EndpointTTLs[endpoint]; ok {
+		return ttl, true
+	}
+	return c.DefaultTTL, true
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			name, arg = part[:i], strings.Trim(part[i+1:], `"`)
+		}
+		directives[strings.ToLower(name)] = arg
+	}
+	return directives
+}
+
+// cachedGet serves the request from the cache if possible and stores cacheable
+// responses. Without a configured cache backend the request is sent as is.
+func (client *Client) cachedGet(ctx context.Context, c *call) (*response, error) {
+	return client.fetchCached(ctx, c, client.apiConfig.CacheConfig.ServeStale)
+}
+
+func (client *Client) fetchCached(ctx context.Context, c *call, serveStale bool) (*response, error) {
+	cfg := client.apiConfig.CacheConfig
+	if cfg.Backend == nil || c.noCache {
+		return client.doRequest(ctx, c, nil)
+	}
+
+	entry, cached := cfg.Backend.Get(c.url)
+	if cached && entry.fresh(time.Now()) {
+		return &response{statusCode: http.StatusOK, body: entry.Body}, nil
+	}
+
+	header := http.Header{}
+	if cached {
+		if entry.ETag != "" {
+			header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := client.doRequest(ctx, c, header)
+	if serveStale && !c.noStale && cached && unavailable(ctx, resp, err) {
+		age := time.Since(entry.StoredAt)
+		if cfg.MaxStaleAge <= 0 || age <= cfg.MaxStaleAge {
+			client.refreshStale(c)
+			return &response{statusCode: http.StatusOK, body: entry.Body, stale: true, age: age}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case resp.statusCode == http.StatusNotModified && cached:
+		revalidated := *entry
+		revalidated.StoredAt = now
+		if etag := resp.header.Get("ETag"); etag != "" {
+			revalidated.ETag = etag
+		}
+		if lastModified := resp.header.Get("Last-Modified"); lastModified != "" {
+			revalidated.LastModified = lastModified
+		}
+		ttl, store := cfg.ttl(c.endpoint, resp.header)
+		revalidated.Expires = now.Add(ttl)
+		if store {
+			cfg.Backend.Set(c.url, &revalidated)
+		} else {
+			cfg.Backend.Delete(c.url)
+		}
+		return &response{statusCode: http.StatusOK, header: resp.header, body: revalidated.Body}, nil
+	case resp.statusCode == http.StatusOK:
+		ttl, store := cfg.ttl(c.endpoint, resp.header)
+		fresh := &CacheEntry{
+			Body:         resp.body,
+			ETag:         resp.header.Get("ETag"),
+			LastModified: resp.header.Get("Last-Modified"),
+			StoredAt:     now,
+			Expires:      now.Add(ttl),
+		}
+		if store && (ttl > 0 || fresh.revalidatable() || cfg.ServeStale) {
+			cfg.Backend.Set(c.url, fresh)
+		} else if cached {
+			cfg.Backend.Delete(c.url)
+		}
+	}
+
+	return resp, nil
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used entry once it
+// holds more than the configured number of entries.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    *list.List
+	index      map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache creates a MemoryCache holding at most maxEntries responses. A value of
+// zero or less disables the limit.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    list.New(),
+		index:      map[string]*list.Element{},
+	}
+}
+
+// Get returns the entry stored for key and marks it as recently used.
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	c.entries.MoveToFront(element)
+	return element.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores entry for key, evicting the least recently used entry if the cache is full.
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.index[key]; ok {
+		element.Value.(*memoryCacheItem).entry = entry
+		c.entries.MoveToFront(element)
+		return
+	}
+
+	c.index[key] = c.entries.PushFront(&memoryCacheItem{key: key, entry: entry})
+	if c.maxEntries > 0 && c.entries.Len() > c.maxEntries {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete removes the entry stored for key.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.index[key]; ok {
+		c.entries.Remove(element)
+		delete(c.index, key)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+// DiskCache is a Cache storing each entry as JSON file in a directory, so cached
+// responses survive restarts of the application.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a DiskCache in dir. The directory is created if it does not exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get reads the entry stored for key. Unreadable entries are treated as missing.
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &CacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set writes entry for key. The file is replaced atomically so concurrent readers never
+// see a partially written entry. Write errors are ignored, the response is simply not cached.
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, ".entry-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Delete removes the entry stored for key.
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
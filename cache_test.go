@@ -0,0 +1,140 @@
+package dbapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var cachedRequests int32
+
+func init() {
+	http.HandleFunc("/cached/stada/v2/", func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&cachedRequests, 1)
+
+		if request.Header.Get("If-None-Match") == `"v1"` {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		filename := "testdata" + request.URL.String()[len("/cached"):] + ".json"
+		dat, _ := ioutil.ReadFile(filename)
+
+		writer.Header().Set("ETag", `"v1"`)
+		writer.Write(dat)
+	})
+}
+
+func TestStationDataAPI_Cache(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr + "/cached"
+	atomic.StoreInt32(&cachedRequests, 0)
+
+	backend := NewMemoryCache(10)
+	c := New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{
+			Backend: backend,
+			EndpointTTLs: map[string]time.Duration{
+				StationDataStationByIDEndpoint: time.Hour,
+			},
+		},
+	})
+	s := c.StationDataAPI()
+
+	for i := 0; i < 3; i++ {
+		stationResp, err := s.StationByID(1)
+		assert.Nil(err)
+		assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+	}
+	assert.Equal(int32(1), atomic.LoadInt32(&cachedRequests))
+
+	// SZentralen have no TTL configured, so each call revalidates with the ETag
+	for i := 0; i < 2; i++ {
+		szResp, err := s.SZentralenByID(15)
+		assert.Nil(err)
+		assert.Equal("Duisburg Hbf", szResp.Result[0].Name)
+	}
+	assert.Equal(int32(3), atomic.LoadInt32(&cachedRequests))
+	assert.Equal(2, backend.Len())
+}
+
+func TestMemoryCache_Eviction(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := NewMemoryCache(2)
+	cache.Set("a", &CacheEntry{Body: []byte("a")})
+	cache.Set("b", &CacheEntry{Body: []byte("b")})
+	cache.Get("a")
+	cache.Set("c", &CacheEntry{Body: []byte("c")})
+
+	_, ok := cache.Get("b")
+	assert.False(ok)
+	entry, ok := cache.Get("a")
+	assert.True(ok)
+	assert.Equal([]byte("a"), entry.Body)
+	assert.Equal(2, cache.Len())
+
+	cache.Delete("a")
+	_, ok = cache.Get("a")
+	assert.False(ok)
+}
+
+func TestDiskCache(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dbapi-cache")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	cache, err := NewDiskCache(dir)
+	assert.Nil(err)
+
+	expires := time.Now().Add(time.Minute).Round(time.Second)
+	cache.Set("http://example.com/stations/1", &CacheEntry{Body: []byte(`{}`), ETag: `"x"`, Expires: expires})
+
+	entry, ok := cache.Get("http://example.com/stations/1")
+	assert.True(ok)
+	assert.Equal([]byte(`{}`), entry.Body)
+	assert.Equal(`"x"`, entry.ETag)
+	assert.True(expires.Equal(entry.Expires))
+
+	cache.Delete("http://example.com/stations/1")
+	_, ok = cache.Get("http://example.com/stations/1")
+	assert.False(ok)
+}
+
+func TestCacheConfig_TTL(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := CacheConfig{
+		DefaultTTL:   time.Minute,
+		EndpointTTLs: map[string]time.Duration{StationDataStationsEndpoint: time.Hour},
+	}
+
+	ttl, store := cfg.ttl(StationDataStationsEndpoint, http.Header{})
+	assert.True(store)
+	assert.Equal(time.Hour, ttl)
+
+	ttl, store = cfg.ttl(StationDataSZentralenEndpoint, http.Header{})
+	assert.True(store)
+	assert.Equal(time.Minute, ttl)
+
+	ttl, store = cfg.ttl(StationDataStationsEndpoint, http.Header{"Cache-Control": {"public, max-age=30"}})
+	assert.True(store)
+	assert.Equal(30*time.Second, ttl)
+
+	ttl, store = cfg.ttl(StationDataStationsEndpoint, http.Header{"Cache-Control": {"no-cache"}})
+	assert.True(store)
+	assert.Equal(time.Duration(0), ttl)
+
+	_, store = cfg.ttl(StationDataStationsEndpoint, http.Header{"Cache-Control": {"no-store"}})
+	assert.False(store)
+}
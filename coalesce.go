@@ -0,0 +1,73 @@
+package dbapi
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup collapses concurrent requests with the same key into a single call whose
+// result is shared by all callers.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a call in progress. The call runs detached from the cancellation of the callers'
+// contexts and is only canceled once every caller waiting for it gave up. It keeps the values,
+// e.g. the trace span, of the context of the caller that started it.
+type flight struct {
+	done    chan struct{}
+	resp    *response
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (*response, error)) (*response, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = map[string]*flight{}
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go g.run(callCtx, key, f, fn)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(ctx context.Context) (*response, error)) {
+	f.resp, f.err = fn(ctx)
+	f.cancel()
+
+	g.mu.Lock()
+	g.forget(key, f)
+	g.mu.Unlock()
+
+	close(f.done)
+}
+
+// forget removes f from the group unless it was already replaced by a newer flight.
+// g.mu must be held.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
@@ -0,0 +1,88 @@
+package dbapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var slowRequests int32
+
+func init() {
+	http.HandleFunc("/slow/stada/v2/", func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&slowRequests, 1)
+		time.Sleep(200 * time.Millisecond)
+
+		filename := "testdata" + request.URL.String()[len("/slow"):] + ".json"
+		dat, _ := ioutil.ReadFile(filename)
+		writer.Write(dat)
+	})
+}
+
+func TestStationDataAPI_CoalesceRequests(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr + "/slow"
+	atomic.StoreInt32(&slowRequests, 0)
+
+	c := New("SomeFakeToken", Config{CoalesceRequests: true})
+	s := c.StationDataAPI()
+
+	canceledCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	names := make([]string, 10)
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stationResp, err := s.StationByIDContext(context.Background(), 1)
+			if err == nil {
+				names[i] = stationResp.Result[0].Name
+			}
+		}(i)
+	}
+
+	// A caller giving up early must not abort the request shared with the others
+	_, err := s.StationByIDContext(canceledCtx, 1)
+	assert.Equal(context.DeadlineExceeded, err)
+
+	wg.Wait()
+	for _, name := range names {
+		assert.Equal("Aachen Hbf", name)
+	}
+	assert.Equal(int32(1), atomic.LoadInt32(&slowRequests))
+}
+
+func TestFlightGroup_CancelLastWaiter(t *testing.T) {
+	assert := assert.New(t)
+
+	var g flightGroup
+	started := make(chan struct{})
+	aborted := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err := g.do(ctx, "key", func(callCtx context.Context) (*response, error) {
+		close(started)
+		<-callCtx.Done()
+		aborted <- callCtx.Err()
+		return nil, callCtx.Err()
+	})
+
+	assert.Equal(context.Canceled, err)
+	assert.Equal(context.Canceled, <-aborted)
+}
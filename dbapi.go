@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // APIURL is made public for testing reasons
@@ -21,23 +23,101 @@ var APIURL = "https://api.deutschebahn.com"
 // See https://developer.deutschebahn.com/store/apis/list for a complete list of
 // available APIs.
 type Client struct {
+	// APIToken is the token given to New.
+	//
+	// Deprecated: New builds the key pool from the token, so changing APIToken afterwards has no
+	// effect. Use Config.APIKeys or Config.Authenticator to configure the credentials.
 	APIToken   string
 	httpClient *http.Client
 	apiConfig  Config
 
+	flights flightGroup
+	tracer  trace.Tracer
+	breaker *circuitBreaker
+	keys    *keyPool
+
+	staleRefreshes staleRefreshes
+
 	stationDataAPI            *StationDataAPI
 	stationDataAPIInitialized sync.Once
+
+	timetablesAPI            *TimetablesAPI
+	timetablesAPIInitialized sync.Once
+
+	fahrplanAPI            *FahrplanAPI
+	fahrplanAPIInitialized sync.Once
+
+	fastaAPI            *FaStaAPI
+	fastaAPIInitialized sync.Once
+
+	parkingAPI            *ParkingAPI
+	parkingAPIInitialized sync.Once
+
+	betriebsstellenAPI            *BetriebsstellenAPI
+	betriebsstellenAPIInitialized sync.Once
+
+	photosAPI            *PhotosAPI
+	photosAPIInitialized sync.Once
+
+	reisezentrenAPI            *ReisezentrenAPI
+	reisezentrenAPIInitialized sync.Once
+
+	wagenreihungAPI            *WagenreihungAPI
+	wagenreihungAPIInitialized sync.Once
 }
 
-// StationDataConfig provides configuration options for the StationData API. Set rateLimitPerMinute to
-// zero if you want to disable rate limiting done in the library.
+// StationDataConfig provides configuration options for the StationData API. Set RateLimitPerMinute to
+// zero if you want to disable rate limiting done in the library. BatchWorkers sets the number of
+// concurrent requests sent by StationsByIDs and defaults to 4.
 type StationDataConfig struct {
-	rateLimitPerMinute int
+	RateLimitPerMinute int
+	BatchWorkers       int
 }
 
 // Config provides configuration for all implemented APIs.
 type Config struct {
-	StationDataConfig StationDataConfig
+	// BaseURL replaces APIURL for this Client, e.g. to point it to a dbapitest.Server.
+	BaseURL string
+
+	StationDataConfig     StationDataConfig
+	TimetablesConfig      TimetablesConfig
+	FahrplanConfig        FahrplanConfig
+	FaStaConfig           FaStaConfig
+	ParkingConfig         ParkingConfig
+	BetriebsstellenConfig BetriebsstellenConfig
+	PhotosConfig          PhotosConfig
+	ReisezentrenConfig    ReisezentrenConfig
+	WagenreihungConfig    WagenreihungConfig
+	CacheConfig           CacheConfig
+	LogConfig             LogConfig
+	CircuitBreaker        CircuitBreakerConfig
+
+	// Instrumentation receives events about every request sent to the APIs, e.g. to export
+	// metrics. See the dbapiprom package for a Prometheus implementation.
+	Instrumentation Instrumentation
+
+	// TracerProvider enables OpenTelemetry tracing of API calls if set. Each logical call,
+	// e.g. StationByIDContext, creates a span with a child span for every HTTP request sent.
+	TracerProvider trace.TracerProvider
+
+	// Middleware wraps the round trip of every request sent to the APIs. The first middleware
+	// is the outermost one: it sees the request first and the response last.
+	Middleware []Middleware
+
+	// Authenticator adds credentials other than a static token, e.g. ClientIDAPIKey or
+	// OAuth2ClientCredentials for the DB API Marketplace, to the key pool of the Client.
+	Authenticator Authenticator
+
+	// APIKeys adds tokens to the key pool of the Client in addition to the token given to New.
+	// Each request is sent with the key that has rate limit budget available first. Keys that
+	// receive a 401, 403 or 429 response are sidelined for KeySidelineDuration (default one
+	// minute) and the request is retried with another key. See Client.KeyUsage.
+	APIKeys             []APIKey
+	KeySidelineDuration time.Duration
+
+	// CoalesceRequests collapses concurrent requests for the same URL into a single HTTP
+	// call whose response is shared by all callers.
+	CoalesceRequests bool
 }
 
 // New creates a new Client and needs an API token. It provides access to all implemented
@@ -46,27 +126,134 @@ func New(token string, apiConfig Config) *Client {
 	return &Client{
 		APIToken: token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: chainMiddleware(nil, apiConfig.Middleware),
 		},
 		apiConfig: apiConfig,
+		tracer:    newTracer(apiConfig.TracerProvider),
+		breaker:   newCircuitBreaker(apiConfig.CircuitBreaker),
+		keys:      newKeyPool(token, apiConfig),
+	}
+}
+
+func (client *Client) baseURL() string {
+	if client.apiConfig.BaseURL != "" {
+		return client.apiConfig.BaseURL
 	}
+	return APIURL
 }
 
 // StationDataAPI provides access to the StationData v2 API located at https://developer.deutschebahn.com/store/apis/info?name=StaDa-Station_Data&version=v2&provider=DBOpenData
 // It is possible to query Stations and 3S-central points either by filter or by id.
 func (client *Client) StationDataAPI() *StationDataAPI {
 	client.stationDataAPIInitialized.Do(func() {
-		var ticker *time.Ticker
-		rateLimitPerMinute := float64(client.apiConfig.StationDataConfig.rateLimitPerMinute)
-		if rateLimitPerMinute > 0 {
-			rate := time.Duration(60.0/rateLimitPerMinute*1000) * time.Millisecond
-			ticker = time.NewTicker(rate)
-		}
 		client.stationDataAPI = &StationDataAPI{
-			client:             client,
-			rateThrottleTicker: ticker,
+			client: client,
 		}
 	})
 
 	return client.stationDataAPI
 }
+
+// TimetablesAPI provides access to the Timetables v1 API located at https://developer.deutschebahn.com/store/apis/info?name=Timetables&version=v1&provider=DBOpenData
+// It is possible to query the planned stops of a station by hour and the changes to them.
+func (client *Client) TimetablesAPI() *TimetablesAPI {
+	client.timetablesAPIInitialized.Do(func() {
+		client.timetablesAPI = &TimetablesAPI{
+			client: client,
+		}
+	})
+
+	return client.timetablesAPI
+}
+
+// FahrplanAPI provides access to the Fahrplan v1 API located at https://developer.deutschebahn.com/store/apis/info?name=Fahrplan-Plus&version=v1&provider=DBOpenData
+// It is possible to search locations, query their departure and arrival boards and the details
+// of a journey.
+func (client *Client) FahrplanAPI() *FahrplanAPI {
+	client.fahrplanAPIInitialized.Do(func() {
+		client.fahrplanAPI = &FahrplanAPI{
+			client: client,
+		}
+	})
+
+	return client.fahrplanAPI
+}
+
+// FaStaAPI provides access to the FaSta v2 API located at https://developer.deutschebahn.com/store/apis/info?name=FaSta-Station_Facilities_Status&version=v2&provider=DBOpenData
+// It is possible to query the state of elevators and escalators by filter, equipment number or
+// station.
+func (client *Client) FaStaAPI() *FaStaAPI {
+	client.fastaAPIInitialized.Do(func() {
+		client.fastaAPI = &FaStaAPI{
+			client: client,
+		}
+	})
+
+	return client.fastaAPI
+}
+
+// ParkingAPI provides access to the Parking v1 API (DB BahnPark) located at https://developer.deutschebahn.com/store/apis/info?name=BahnPark&version=v1&provider=DBOpenData
+// It is possible to query parking spaces, their prices and opening hours and their current
+// occupancy.
+func (client *Client) ParkingAPI() *ParkingAPI {
+	client.parkingAPIInitialized.Do(func() {
+		client.parkingAPI = &ParkingAPI{
+			client: client,
+		}
+	})
+
+	return client.parkingAPI
+}
+
+// BetriebsstellenAPI provides access to the Betriebsstellen v1 API located at https://developer.deutschebahn.com/store/apis/info?name=Betriebsstellen&version=v1&provider=DBOpenData
+// It is possible to look up operating points, e.g. stations, junctions and block posts, by
+// their DS100 abbreviation or name.
+func (client *Client) BetriebsstellenAPI() *BetriebsstellenAPI {
+	client.betriebsstellenAPIInitialized.Do(func() {
+		client.betriebsstellenAPI = &BetriebsstellenAPI{
+			client: client,
+		}
+	})
+
+	return client.betriebsstellenAPI
+}
+
+// PhotosAPI provides access to the Bahnhofsfotos v1 API of railway-stations.org located at https://developer.deutschebahn.com/store/apis/info?name=Bahnhofsfotos&version=v1&provider=DBOpenData
+// It is possible to query the photos of stations by country and id, including photographer and
+// license.
+func (client *Client) PhotosAPI() *PhotosAPI {
+	client.photosAPIInitialized.Do(func() {
+		client.photosAPI = &PhotosAPI{
+			client: client,
+		}
+	})
+
+	return client.photosAPI
+}
+
+// ReisezentrenAPI provides access to the Reisezentren v1 API located at https://developer.deutschebahn.com/store/apis/info?name=Reisezentren&version=v1&provider=DBOpenData
+// It is possible to query travel centers by name, id or location along with their opening
+// hours.
+func (client *Client) ReisezentrenAPI() *ReisezentrenAPI {
+	client.reisezentrenAPIInitialized.Do(func() {
+		client.reisezentrenAPI = &ReisezentrenAPI{
+			client: client,
+		}
+	})
+
+	return client.reisezentrenAPI
+}
+
+// WagenreihungAPI provides access to the Wagenreihung v1 API, which returns the coach sequence
+// of long-distance trains at a stop, including platform sectors, classes and facilities of the
+// coaches.
+func (client *Client) WagenreihungAPI() *WagenreihungAPI {
+	client.wagenreihungAPIInitialized.Do(func() {
+		client.wagenreihungAPI = &WagenreihungAPI{
+			client: client,
+		}
+	})
+
+	return client.wagenreihungAPI
+}
@@ -1,7 +1,11 @@
 package dbapi
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"mime"
+	"net/http"
 	"net/http/httptest"
 	"sync"
 )
@@ -16,3 +20,30 @@ func startMockServer() {
 	serverAddr = server.Listener.Addr().String()
 	log.Println("Testserver listening on ", serverAddr)
 }
+
+// serveTestdata registers a handler on the default mux answering requests below prefix, e.g.
+// "/bahnpark/v1/", with the file in testdata named after the request path plus extension.
+// Requests without file are answered with 404 and notFound as body.
+func serveTestdata(prefix, extension, notFound string) {
+	http.HandleFunc(prefix, func(writer http.ResponseWriter, request *http.Request) {
+		dat, err := ioutil.ReadFile("testdata" + request.URL.Path + extension)
+		if err != nil {
+			writer.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(writer, notFound)
+			return
+		}
+
+		writer.Header().Set("Content-Type", mime.TypeByExtension(extension))
+		writer.Write(dat)
+	})
+}
+
+// captureQuery returns a Middleware storing the raw query of the last request in query.
+func captureQuery(query *string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*query = req.URL.RawQuery
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package dbapiprom exports metrics about the requests sent by a dbapi.Client to Prometheus.
+//
+// Create the collector, register it and pass it as Instrumentation in the dbapi.Config:
+//
+//	metrics := dbapiprom.New("myapp")
+//	prometheus.MustRegister(metrics)
+//
+//	client := dbapi.New("your token", dbapi.Config{
+//		Instrumentation: metrics,
+//	})
+package dbapiprom
+
+import (
+	"strconv"
+	"time"
+
+	dbapi "github.com/amuttsch/go-db-api"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements dbapi.Instrumentation and prometheus.Collector. It records the
+// following metrics, prefixed with the namespace given to New:
+//
+//	dbapi_requests_total{api,endpoint,code}           counter, code is "error" for failed requests
+//	dbapi_request_duration_seconds{api,endpoint}      histogram
+//	dbapi_requests_in_flight{api}                     gauge
+//	dbapi_rate_limit_wait_seconds{api}                histogram
+//	dbapi_retries_total{api,endpoint}                 counter
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	inFlight      *prometheus.GaugeVec
+	rateLimitWait *prometheus.HistogramVec
+	retries       *prometheus.CounterVec
+}
+
+// New creates the collectors. namespace may be empty.
+func New(namespace string) *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dbapi",
+			Name:      "requests_total",
+			Help:      "Number of requests sent to the DB APIs by endpoint and status code.",
+		}, []string{"api", "endpoint", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "dbapi",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests sent to the DB APIs, excluding rate limiting.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"api", "endpoint"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "dbapi",
+			Name:      "requests_in_flight",
+			Help:      "Number of requests to the DB APIs currently in flight.",
+		}, []string{"api"}),
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "dbapi",
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time requests were held back by the client side rate limiter.",
+			Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"api"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dbapi",
+			Name:      "retries_total",
+			Help:      "Number of retried requests to the DB APIs.",
+		}, []string{"api", "endpoint"}),
+	}
+}
+
+// RequestStarted implements dbapi.Instrumentation.
+func (m *Metrics) RequestStarted(info dbapi.RequestInfo) {
+	m.inFlight.WithLabelValues(info.API).Inc()
+	if info.Attempt > 1 {
+		m.retries.WithLabelValues(info.API, info.Endpoint).Inc()
+	}
+}
+
+// RequestFinished implements dbapi.Instrumentation.
+func (m *Metrics) RequestFinished(info dbapi.RequestInfo, statusCode int, duration time.Duration, err error) {
+	m.inFlight.WithLabelValues(info.API).Dec()
+
+	code := "error"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	m.requests.WithLabelValues(info.API, info.Endpoint, code).Inc()
+	m.duration.WithLabelValues(info.API, info.Endpoint).Observe(duration.Seconds())
+}
+
+// RateLimitWaited implements dbapi.Instrumentation.
+func (m *Metrics) RateLimitWaited(info dbapi.RequestInfo, wait time.Duration) {
+	m.rateLimitWait.WithLabelValues(info.API).Observe(wait.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+	m.inFlight.Describe(ch)
+	m.rateLimitWait.Describe(ch)
+	m.retries.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+	m.inFlight.Collect(ch)
+	m.rateLimitWait.Collect(ch)
+	m.retries.Collect(ch)
+}
@@ -0,0 +1,49 @@
+package dbapiprom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbapi "github.com/amuttsch/go-db-api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/stada/v2/stations/1" {
+			fmt.Fprint(writer, `{"total":1,"result":[{"number":1,"name":"Aachen Hbf"}]}`)
+			return
+		}
+		writer.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(writer, `{"error":{"code":900802,"message":"Message throttled out"}}`)
+	}))
+	defer server.Close()
+	dbapi.APIURL = server.URL
+
+	metrics := New("test")
+	registry := prometheus.NewPedanticRegistry()
+	assert.Nil(registry.Register(metrics))
+
+	c := dbapi.New("SomeFakeToken", dbapi.Config{Instrumentation: metrics})
+	s := c.StationDataAPI()
+
+	s.StationByID(1)
+	s.StationByID(1)
+	_, err := s.StationByID(2)
+	assert.NotNil(err)
+
+	assert.Equal(2.0, testutil.ToFloat64(metrics.requests.WithLabelValues("stationdata", dbapi.StationDataStationByIDEndpoint, "200")))
+	assert.Equal(1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("stationdata", dbapi.StationDataStationByIDEndpoint, "429")))
+	assert.Equal(0.0, testutil.ToFloat64(metrics.inFlight.WithLabelValues("stationdata")))
+	assert.Equal(0.0, testutil.ToFloat64(metrics.retries.WithLabelValues("stationdata", dbapi.StationDataStationByIDEndpoint)))
+
+	count, err := testutil.GatherAndCount(registry, "test_dbapi_request_duration_seconds")
+	assert.Nil(err)
+	assert.Equal(1, count)
+}
@@ -0,0 +1,256 @@
+package dbapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+
+	dbapi "github.com/amuttsch/go-db-api"
+)
+
+// scrubbedHeaders are replaced by scrubbedValue before an interaction is recorded.
+var scrubbedHeaders = []string{"Authorization", "DB-Api-Key", "DB-Client-Id"}
+
+const scrubbedValue = "REDACTED"
+
+// Interaction is a request/response pair stored in a Cassette.
+type Interaction struct {
+	Request  InteractionRequest  `json:"request"`
+	Response InteractionResponse `json:"response"`
+}
+
+// InteractionRequest is the recorded part of a request. Query is normalized, i.e. sorted by
+// parameter name and value.
+type InteractionRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// InteractionResponse is a recorded response. JSON bodies are stored indented in JSON to keep
+// cassettes diff-friendly, all other bodies are stored as string in Body.
+type InteractionResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Header     http.Header     `json:"header,omitempty"`
+	JSON       json.RawMessage `json:"json,omitempty"`
+	Body       string          `json:"body,omitempty"`
+}
+
+// Cassette records the interactions of a dbapi.Client with the DB APIs to a file and replays
+// them without network access. Credentials are scrubbed from recorded requests. Use
+// Cassette.Middleware as dbapi.Middleware. Replay needs a token, but any dummy value does:
+//
+//	c, err := dbapitest.LoadCassette("testdata/stations.json")
+//	client := dbapi.New("replay", dbapi.Config{
+//		Middleware: []dbapi.Middleware{c.Middleware},
+//	})
+//
+// In replay mode, requests are matched on method, path and normalized query. Identical
+// requests are answered with their recorded responses in order. A request without an
+// unplayed match fails with an error.
+type Cassette struct {
+	path      string
+	recording bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	played       []bool
+}
+
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// NewRecorder creates a Cassette that sends requests to the API and records them. Call Save
+// to write the interactions to path.
+func NewRecorder(path string) *Cassette {
+	return &Cassette{path: path, recording: true}
+}
+
+// LoadCassette reads the interactions stored at path for replay.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("dbapitest: invalid cassette %s: %w", path, err)
+	}
+
+	return &Cassette{
+		path:         path,
+		interactions: file.Interactions,
+		played:       make([]bool, len(file.Interactions)),
+	}, nil
+}
+
+// Middleware records or replays requests, depending on how the Cassette was created. It
+// implements dbapi.Middleware.
+func (c *Cassette) Middleware(next http.RoundTripper) http.RoundTripper {
+	return dbapi.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if c.recording {
+			return c.record(next, req)
+		}
+		return c.replay(req)
+	})
+}
+
+// Save writes the recorded interactions to the path given to NewRecorder.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// Unplayed returns the interactions that have not been replayed yet, e.g. to assert that a
+// test sent all expected requests.
+func (c *Cassette) Unplayed() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var unplayed []Interaction
+	for i, played := range c.played {
+		if !played {
+			unplayed = append(unplayed, c.interactions[i])
+		}
+	}
+	return unplayed
+}
+
+func (c *Cassette) record(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request: InteractionRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  normalizeQuery(req.URL.Query()),
+			Header: scrub(req.Header),
+		},
+		Response: InteractionResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrub(resp.Header),
+		},
+	}
+	var indented bytes.Buffer
+	if len(body) > 0 && json.Indent(&indented, body, "", "  ") == nil {
+		interaction.Response.JSON = indented.Bytes()
+	} else {
+		interaction.Response.Body = string(body)
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mu.Unlock()
+
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	query := normalizeQuery(req.URL.Query())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matched := 0
+	for i, interaction := range c.interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != req.URL.Path ||
+			interaction.Request.Query != query {
+			continue
+		}
+		matched++
+		if c.played[i] {
+			continue
+		}
+		c.played[i] = true
+		return interaction.Response.toHTTP(req)
+	}
+
+	target := req.URL.Path
+	if query != "" {
+		target += "?" + query
+	}
+	if matched > 0 {
+		return nil, fmt.Errorf("dbapitest: all %d interactions for %s %s in cassette %s have been played",
+			matched, req.Method, target, c.path)
+	}
+	return nil, fmt.Errorf("dbapitest: no interaction for %s %s in cassette %s", req.Method, target, c.path)
+}
+
+func (r InteractionResponse) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if len(r.JSON) > 0 {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, r.JSON); err != nil {
+			return nil, err
+		}
+		body = compact.Bytes()
+	}
+
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalizeQuery encodes query sorted by parameter name and value.
+func normalizeQuery(query url.Values) string {
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	return query.Encode()
+}
+
+// scrub returns a copy of header with credentials replaced.
+func scrub(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	scrubbed := header.Clone()
+	for _, name := range scrubbedHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, scrubbedValue)
+		}
+	}
+	// Content-Length is recomputed on replay, Date would only add noise to diffs
+	scrubbed.Del("Content-Length")
+	scrubbed.Del("Date")
+	return scrubbed
+}
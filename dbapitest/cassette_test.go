@@ -0,0 +1,87 @@
+package dbapitest
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dbapi "github.com/amuttsch/go-db-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette_RecordReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "stations.json")
+
+	srv := NewServer()
+	recorder := NewRecorder(path)
+	s := dbapi.New(Token, dbapi.Config{
+		BaseURL:    srv.URL,
+		Middleware: []dbapi.Middleware{recorder.Middleware},
+	}).StationDataAPI()
+
+	stationResp, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+	_, err = s.StationByFilter(dbapi.StationDataStationRequest{Searchstring: "Frankfurt*", Federalstate: "hessen"})
+	assert.Nil(err)
+	_, err = s.StationByID(2)
+	assert.NotNil(err)
+
+	srv.Close()
+	assert.Nil(recorder.Save())
+
+	data, err := os.ReadFile(path)
+	assert.Nil(err)
+	assert.NotContains(string(data), Token)
+	assert.Contains(string(data), `"Authorization": [
+            "REDACTED"
+          ]`)
+
+	cassette, err := LoadCassette(path)
+	assert.Nil(err)
+	// Replay as documented, without base URL and with a dummy token
+	s = dbapi.New("replay", dbapi.Config{
+		Middleware: []dbapi.Middleware{cassette.Middleware},
+	}).StationDataAPI()
+
+	assert.Len(cassette.Unplayed(), 3)
+
+	replayed, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal(stationResp, replayed)
+
+	filterResp, err := s.StationByFilter(dbapi.StationDataStationRequest{Searchstring: "Frankfurt*", Federalstate: "hessen"})
+	assert.Nil(err)
+	assert.Len(filterResp.Result, 3)
+
+	_, err = s.StationByID(2)
+	assert.Equal(&dbapi.StationDataErrorResponse{ErrNo: 404, ErrMsg: "Resource not found"}, err)
+	assert.Empty(cassette.Unplayed())
+
+	_, err = s.StationByID(1)
+	assert.ErrorContains(err, "all 1 interactions for GET /stada/v2/stations/1")
+	_, err = s.StationByID(3)
+	assert.ErrorContains(err, "no interaction for GET /stada/v2/stations/3")
+}
+
+func TestCassette_NormalizeQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("a=1&a=2&b=3", normalizeQuery(url.Values{"b": {"3"}, "a": {"2", "1"}}))
+	assert.Equal("", normalizeQuery(url.Values{}))
+}
+
+func TestLoadCassette(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := LoadCassette(filepath.Join(t.TempDir(), "missing.json"))
+	assert.True(os.IsNotExist(err))
+
+	path := filepath.Join(t.TempDir(), "invalid.json")
+	assert.Nil(os.WriteFile(path, []byte("{"), 0o644))
+	_, err = LoadCassette(path)
+	assert.ErrorContains(err, "invalid cassette")
+}
@@ -0,0 +1,110 @@
+package dbapitest
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	dbapi "github.com/amuttsch/go-db-api"
+)
+
+// stationFilter implements the filter parameters of the StationData stations endpoint.
+type stationFilter struct {
+	searchstring *regexp.Regexp
+	categoryMin  int
+	categoryMax  int
+	federalstate string
+	eva          int
+	ril          string
+	or           bool
+}
+
+func parseStationFilter(query url.Values) (*stationFilter, error) {
+	filter := &stationFilter{
+		federalstate: query.Get("federalstate"),
+		ril:          query.Get("ril"),
+	}
+
+	if searchstring := query.Get("searchstring"); searchstring != "" {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(searchstring), `\*`, ".*")
+		filter.searchstring = regexp.MustCompile("(?i)^" + pattern + "$")
+	}
+
+	if category := query.Get("category"); category != "" {
+		bounds := strings.SplitN(category, "-", 2)
+		min, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, errors.New("Invalid category")
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, errors.New("Invalid category")
+			}
+		}
+		filter.categoryMin, filter.categoryMax = min, max
+	}
+
+	if eva := query.Get("eva"); eva != "" {
+		number, err := strconv.Atoi(eva)
+		if err != nil {
+			return nil, errors.New("Invalid eva number")
+		}
+		filter.eva = number
+	}
+
+	switch strings.ToLower(query.Get("logicaloperator")) {
+	case "", "and":
+	case "or":
+		filter.or = true
+	default:
+		return nil, errors.New("Invalid logical operator")
+	}
+
+	return filter, nil
+}
+
+// matches reports whether station satisfies all set criteria, or any of them if the
+// logical operator is "or". A filter without criteria matches all stations.
+func (f *stationFilter) matches(station dbapi.Station) bool {
+	var results []bool
+
+	if f.searchstring != nil {
+		results = append(results, f.searchstring.MatchString(station.Name))
+	}
+	if f.categoryMin != 0 {
+		results = append(results, station.Category >= f.categoryMin && station.Category <= f.categoryMax)
+	}
+	if f.federalstate != "" {
+		results = append(results, strings.EqualFold(station.FederalState, f.federalstate))
+	}
+	if f.eva != 0 {
+		found := false
+		for _, eva := range station.EvaNumbers {
+			found = found || eva.Number == f.eva
+		}
+		results = append(results, found)
+	}
+	if f.ril != "" {
+		found := false
+		for _, ril := range station.Ril100Identifiers {
+			found = found || strings.EqualFold(ril.RilIdentifier, f.ril)
+		}
+		results = append(results, found)
+	}
+
+	if len(results) == 0 {
+		return true
+	}
+	for _, result := range results {
+		if f.or && result {
+			return true
+		}
+		if !f.or && !result {
+			return false
+		}
+	}
+	return !f.or
+}
@@ -0,0 +1,250 @@
+package dbapitest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	dbapi "github.com/amuttsch/go-db-api"
+	"github.com/google/go-querystring/query"
+)
+
+var _ dbapi.StationDataService = (*MemoryStationData)(nil)
+
+// MemoryStationData is an in-memory dbapi.StationDataService seeded with the same data as
+// the Server. It implements the filter semantics of the StationData API and returns the
+// same errors as the API, e.g. a *dbapi.StationDataErrorResponse with ErrNo 404 for unknown
+// ids. It is safe for concurrent use.
+type MemoryStationData struct {
+	mu         sync.RWMutex
+	stations   []dbapi.Station
+	szentralen []dbapi.SZentrale
+}
+
+// NewMemoryStationData creates a MemoryStationData seeded with stations from Hessen,
+// Aachen Hbf and all SZentralen.
+func NewMemoryStationData() *MemoryStationData {
+	data := struct {
+		Stations   []dbapi.Station   `json:"stations"`
+		SZentralen []dbapi.SZentrale `json:"szentralen"`
+	}{}
+	if err := json.Unmarshal(seed, &data); err != nil {
+		panic("dbapitest: invalid seed data: " + err.Error())
+	}
+
+	m := &MemoryStationData{stations: data.Stations}
+	m.SetSZentralen(data.SZentralen)
+	return m
+}
+
+// SetStations replaces the stations.
+func (m *MemoryStationData) SetStations(stations []dbapi.Station) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stations = append([]dbapi.Station(nil), stations...)
+}
+
+// SetSZentralen replaces the SZentralen.
+func (m *MemoryStationData) SetSZentralen(szentralen []dbapi.SZentrale) {
+	sorted := append([]dbapi.SZentrale(nil), szentralen...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.szentralen = sorted
+}
+
+// StationByID implements dbapi.StationDataService.
+func (m *MemoryStationData) StationByID(id int) (*dbapi.StationDataStationResponse, error) {
+	return m.StationByIDContext(context.Background(), id)
+}
+
+// StationByIDContext implements dbapi.StationDataService.
+func (m *MemoryStationData) StationByIDContext(ctx context.Context, id int) (*dbapi.StationDataStationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	station, ok := m.station(id)
+	if !ok {
+		return nil, notFound()
+	}
+	return &dbapi.StationDataStationResponse{Limit: 1, Total: 1, Result: []dbapi.Station{station}}, nil
+}
+
+// StationByFilter implements dbapi.StationDataService.
+func (m *MemoryStationData) StationByFilter(stationRequest dbapi.StationDataStationRequest) (*dbapi.StationDataStationResponse, error) {
+	return m.StationByFilterContext(context.Background(), stationRequest)
+}
+
+// StationByFilterContext implements dbapi.StationDataService.
+func (m *MemoryStationData) StationByFilterContext(ctx context.Context, stationRequest dbapi.StationDataStationRequest) (*dbapi.StationDataStationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	q, err := query.Values(stationRequest)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parseStationFilter(q)
+	if err != nil {
+		return nil, &dbapi.StationDataErrorResponse{ErrNo: http.StatusBadRequest, ErrMsg: err.Error()}
+	}
+
+	matches := m.filterStations(filter)
+	if len(matches) == 0 {
+		return nil, notFound()
+	}
+
+	offset, limit := normalizePage(stationRequest.Offset, stationRequest.Limit)
+	return &dbapi.StationDataStationResponse{
+		Offset: offset,
+		Limit:  limit,
+		Total:  len(matches),
+		Result: window(matches, offset, limit),
+	}, nil
+}
+
+// StationAll implements dbapi.StationDataService.
+func (m *MemoryStationData) StationAll() (*dbapi.StationDataStationResponse, error) {
+	return m.StationByFilter(dbapi.StationDataStationRequest{})
+}
+
+// StationAllContext implements dbapi.StationDataService.
+func (m *MemoryStationData) StationAllContext(ctx context.Context) (*dbapi.StationDataStationResponse, error) {
+	return m.StationByFilterContext(ctx, dbapi.StationDataStationRequest{})
+}
+
+// StationsByIDs implements dbapi.StationDataService.
+func (m *MemoryStationData) StationsByIDs(ctx context.Context, ids []int) (map[int]dbapi.Station, map[int]error) {
+	stations := map[int]dbapi.Station{}
+	errs := map[int]error{}
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			errs[id] = err
+			continue
+		}
+		if station, ok := m.station(id); ok {
+			stations[id] = station
+		} else {
+			errs[id] = notFound()
+		}
+	}
+	return stations, errs
+}
+
+// SZentralenByID implements dbapi.StationDataService.
+func (m *MemoryStationData) SZentralenByID(id int) (*dbapi.StationDataSZentralenResponse, error) {
+	return m.SZentralenByIDContext(context.Background(), id)
+}
+
+// SZentralenByIDContext implements dbapi.StationDataService.
+func (m *MemoryStationData) SZentralenByIDContext(ctx context.Context, id int) (*dbapi.StationDataSZentralenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	szentrale, ok := m.szentrale(id)
+	if !ok {
+		return nil, notFound()
+	}
+	return &dbapi.StationDataSZentralenResponse{Limit: 1, Total: 1, Result: []dbapi.SZentrale{szentrale}}, nil
+}
+
+// SZentralenByFilter implements dbapi.StationDataService.
+func (m *MemoryStationData) SZentralenByFilter(szentralenRequest dbapi.StationDataSZentralenRequest) (*dbapi.StationDataSZentralenResponse, error) {
+	return m.SZentralenByFilterContext(context.Background(), szentralenRequest)
+}
+
+// SZentralenByFilterContext implements dbapi.StationDataService.
+func (m *MemoryStationData) SZentralenByFilterContext(ctx context.Context, szentralenRequest dbapi.StationDataSZentralenRequest) (*dbapi.StationDataSZentralenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	szentralen := m.szentralen
+	m.mu.RUnlock()
+
+	offset, limit := normalizePage(szentralenRequest.Offset, szentralenRequest.Limit)
+	return &dbapi.StationDataSZentralenResponse{
+		Offset: offset,
+		Limit:  limit,
+		Total:  len(szentralen),
+		Result: window(szentralen, offset, limit),
+	}, nil
+}
+
+// SZentralenAll implements dbapi.StationDataService.
+func (m *MemoryStationData) SZentralenAll() (*dbapi.StationDataSZentralenResponse, error) {
+	return m.SZentralenByFilter(dbapi.StationDataSZentralenRequest{})
+}
+
+// SZentralenAllContext implements dbapi.StationDataService.
+func (m *MemoryStationData) SZentralenAllContext(ctx context.Context) (*dbapi.StationDataSZentralenResponse, error) {
+	return m.SZentralenByFilterContext(ctx, dbapi.StationDataSZentralenRequest{})
+}
+
+func (m *MemoryStationData) station(id int) (dbapi.Station, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, station := range m.stations {
+		if station.Number == id {
+			return station, true
+		}
+	}
+	return dbapi.Station{}, false
+}
+
+func (m *MemoryStationData) szentrale(id int) (dbapi.SZentrale, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, szentrale := range m.szentralen {
+		if szentrale.Number == id {
+			return szentrale, true
+		}
+	}
+	return dbapi.SZentrale{}, false
+}
+
+func (m *MemoryStationData) filterStations(filter *stationFilter) []dbapi.Station {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []dbapi.Station
+	for _, station := range m.stations {
+		if filter.matches(station) {
+			matches = append(matches, station)
+		}
+	}
+	return matches
+}
+
+func notFound() error {
+	return &dbapi.StationDataErrorResponse{ErrNo: http.StatusNotFound, ErrMsg: "Resource not found"}
+}
+
+// normalizePage applies the defaults of the API to offset and limit. A limit of zero means
+// the default limit.
+func normalizePage(offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > defaultLimit {
+		limit = defaultLimit
+	}
+	return offset, limit
+}
+
+// window returns the page of items selected by offset and limit.
+func window[T any](items []T, offset, limit int) []T {
+	return items[min(offset, len(items)):min(offset+limit, len(items))]
+}
@@ -0,0 +1,69 @@
+package dbapitest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbapi "github.com/amuttsch/go-db-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStationData(t *testing.T) {
+	assert := assert.New(t)
+
+	var s dbapi.StationDataService = NewMemoryStationData()
+
+	stationResp, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+
+	_, err = s.StationByID(2)
+	assert.Equal(&dbapi.StationDataErrorResponse{ErrNo: 404, ErrMsg: "Resource not found"}, err)
+
+	stationResp, err = s.StationByFilter(dbapi.StationDataStationRequest{Searchstring: "Frankfurt*", Limit: 2})
+	assert.Nil(err)
+	assert.Equal(3, stationResp.Total)
+	assert.Len(stationResp.Result, 2)
+
+	_, err = s.StationByFilter(dbapi.StationDataStationRequest{Category: "x"})
+	assert.Equal(&dbapi.StationDataErrorResponse{ErrNo: 400, ErrMsg: "Invalid category"}, err)
+
+	stationResp, err = s.StationAll()
+	assert.Nil(err)
+	assert.Equal(10, stationResp.Total)
+
+	stations, errs := s.StationsByIDs(context.Background(), []int{1, 1866, 2})
+	assert.Len(stations, 2)
+	assert.Len(errs, 1)
+
+	szResp, err := s.SZentralenAll()
+	assert.Nil(err)
+	assert.Equal(30, szResp.Total)
+
+	szResp, err = s.SZentralenByID(15)
+	assert.Nil(err)
+	assert.Equal("Duisburg Hbf", szResp.Result[0].Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.StationByIDContext(ctx, 1)
+	assert.Equal(context.Canceled, err)
+}
+
+func TestMemoryStationData_Caching(t *testing.T) {
+	assert := assert.New(t)
+
+	memory := NewMemoryStationData()
+	s := dbapi.NewCachingStationData(memory, nil, time.Minute)
+
+	memory.SetStations([]dbapi.Station{{Number: 1, Name: "Before"}})
+	stationResp, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal("Before", stationResp.Result[0].Name)
+
+	memory.SetStations([]dbapi.Station{{Number: 1, Name: "After"}})
+	stationResp, err = s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal("Before", stationResp.Result[0].Name)
+}
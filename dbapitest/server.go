@@ -0,0 +1,366 @@
+// Package dbapitest provides an in-process fake of the DB APIs for testing code that uses
+// the dbapi package.
+//
+// The Server is seeded with a small set of stations and all SZentralen and implements the
+// filter semantics of the StationData API. It checks credentials, can delay responses and
+// fail requests on demand and records all requests for assertions:
+//
+//	srv := dbapitest.NewServer()
+//	defer srv.Close()
+//
+//	client := dbapi.New(dbapitest.Token, dbapi.Config{BaseURL: srv.URL})
+//	resp, err := client.StationDataAPI().StationByFilter(dbapi.StationDataStationRequest{
+//		Federalstate: "hessen",
+//	})
+//
+// A Cassette records interactions with the real APIs once and replays them in tests without
+// network access.
+package dbapitest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dbapi "github.com/amuttsch/go-db-api"
+)
+
+// Token is the bearer token accepted by a new Server.
+const Token = "dbapitest-token"
+
+const (
+	stadaAPIPath = "/stada/v2"
+	defaultLimit = 10000
+)
+
+//go:embed seed.json
+var seed []byte
+
+// RecordedRequest is a request received by the Server.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Time   time.Time
+}
+
+// Server is a fake of the DB APIs listening on a local address. All methods are safe for
+// concurrent use.
+type Server struct {
+	// URL is the base URL of the server, to be used as dbapi.Config.BaseURL.
+	URL string
+
+	server *httptest.Server
+
+	data *MemoryStationData
+
+	mu          sync.Mutex
+	tokens      map[string]bool
+	apiKeys     map[string]string
+	latency     time.Duration
+	failures    []failure
+	requests    []RecordedRequest
+	checkTokens bool
+}
+
+type failure struct {
+	status int
+	// remaining is the number of requests still to fail, -1 fails all requests.
+	remaining int
+}
+
+// NewServer starts a Server seeded with stations from Hessen, Aachen Hbf and all SZentralen,
+// accepting Token as bearer token.
+func NewServer() *Server {
+	s := &Server{
+		tokens:      map[string]bool{Token: true},
+		apiKeys:     map[string]string{},
+		checkTokens: true,
+		data:        NewMemoryStationData(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(stadaAPIPath+"/stations", s.handleStations)
+	mux.HandleFunc(stadaAPIPath+"/stations/", s.handleStationByID)
+	mux.HandleFunc(stadaAPIPath+"/szentralen", s.handleSZentralen)
+	mux.HandleFunc(stadaAPIPath+"/szentralen/", s.handleSZentraleByID)
+
+	s.server = httptest.NewServer(s.middleware(mux))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// SetStations replaces the stations served.
+func (s *Server) SetStations(stations []dbapi.Station) {
+	s.data.SetStations(stations)
+}
+
+// SetSZentralen replaces the SZentralen served.
+func (s *Server) SetSZentralen(szentralen []dbapi.SZentrale) {
+	s.data.SetSZentralen(szentralen)
+}
+
+// AddToken makes the server accept token as bearer token.
+func (s *Server) AddToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token] = true
+}
+
+// AddClientID makes the server accept the DB-Client-Id and DB-Api-Key header pair.
+func (s *Server) AddClientID(clientID, apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys[clientID] = apiKey
+}
+
+// DisableTokenCheck makes the server accept requests without valid credentials.
+func (s *Server) DisableTokenCheck() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkTokens = false
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// FailNext answers the next n requests with status, e.g. http.StatusTooManyRequests. The
+// response bodies match the errors sent by the real API. Failures queue up if called
+// repeatedly.
+func (s *Server) FailNext(status, n int) {
+	if n <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure{status: status, remaining: n})
+}
+
+// FailAll answers all requests with status until ClearFailures is called.
+func (s *Server) FailAll(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = []failure{{status: status, remaining: -1}}
+}
+
+// ClearFailures removes all failures set by FailNext and FailAll.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = nil
+}
+
+// Requests returns all requests received so far, including rejected ones.
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// ResetRequests clears the recorded requests.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+// middleware records the request and applies latency, credential checks and failures.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, RecordedRequest{
+			Method: request.Method,
+			Path:   request.URL.Path,
+			Query:  request.URL.Query(),
+			Header: request.Header.Clone(),
+			Time:   time.Now(),
+		})
+		latency := s.latency
+		authorized := !s.checkTokens || s.authorized(request)
+		status := s.nextFailure()
+		s.mu.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-request.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case !authorized:
+			writeRateError(writer, http.StatusUnauthorized, 900901, "Invalid Credentials",
+				"Access failure for API: "+stadaAPIPath+", version: v2. Make sure you have given the correct access token")
+		case status != 0:
+			writeFailure(writer, status)
+		default:
+			next.ServeHTTP(writer, request)
+		}
+	})
+}
+
+// authorized checks the credentials of request. s.mu must be held.
+func (s *Server) authorized(request *http.Request) bool {
+	if token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "); s.tokens[token] {
+		return true
+	}
+	apiKey, ok := s.apiKeys[request.Header.Get("DB-Client-Id")]
+	return ok && apiKey == request.Header.Get("DB-Api-Key")
+}
+
+// nextFailure returns the status the current request has to fail with, or zero. s.mu must be held.
+func (s *Server) nextFailure() int {
+	if len(s.failures) == 0 {
+		return 0
+	}
+
+	f := &s.failures[0]
+	status := f.status
+	if f.remaining > 0 {
+		f.remaining--
+		if f.remaining == 0 {
+			s.failures = s.failures[1:]
+		}
+	}
+	return status
+}
+
+func (s *Server) handleStations(writer http.ResponseWriter, request *http.Request) {
+	filter, err := parseStationFilter(request.URL.Query())
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	matches := s.data.filterStations(filter)
+	if len(matches) == 0 {
+		writeError(writer, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	offset, limit := page(request.URL.Query())
+	writeJSON(writer, dbapi.StationDataStationResponse{
+		Offset: offset,
+		Limit:  limit,
+		Total:  len(matches),
+		Result: window(matches, offset, limit),
+	})
+}
+
+func (s *Server) handleStationByID(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(request.URL.Path, stadaAPIPath+"/stations/"))
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "Invalid station number")
+		return
+	}
+
+	s.writeResponse(writer, func() (interface{}, error) {
+		return s.data.StationByID(id)
+	})
+}
+
+func (s *Server) handleSZentralen(writer http.ResponseWriter, request *http.Request) {
+	offset, limit := page(request.URL.Query())
+	s.writeResponse(writer, func() (interface{}, error) {
+		return s.data.SZentralenByFilter(dbapi.StationDataSZentralenRequest{Offset: offset, Limit: limit})
+	})
+}
+
+func (s *Server) handleSZentraleByID(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(request.URL.Path, stadaAPIPath+"/szentralen/"))
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "Invalid szentrale number")
+		return
+	}
+
+	s.writeResponse(writer, func() (interface{}, error) {
+		return s.data.SZentralenByID(id)
+	})
+}
+
+// writeResponse writes the result of a MemoryStationData call.
+func (s *Server) writeResponse(writer http.ResponseWriter, call func() (interface{}, error)) {
+	data, err := call()
+	if errResp, ok := err.(*dbapi.StationDataErrorResponse); ok {
+		writeError(writer, errResp.ErrNo, errResp.ErrMsg)
+		return
+	}
+	if err != nil {
+		writeError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(writer, data)
+}
+
+// page returns offset and limit of the request. The parameter names are matched
+// case-insensitively, a limit of zero means the default limit.
+func page(query url.Values) (int, int) {
+	offset, _ := strconv.Atoi(queryValue(query, "offset"))
+	limit, _ := strconv.Atoi(queryValue(query, "limit"))
+	return normalizePage(offset, limit)
+}
+
+func queryValue(query url.Values, name string) string {
+	for key, values := range query {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func writeJSON(writer http.ResponseWriter, data interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(data)
+}
+
+func writeError(writer http.ResponseWriter, status int, message string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(dbapi.StationDataErrorResponse{ErrNo: status, ErrMsg: message})
+}
+
+func writeRateError(writer http.ResponseWriter, status, code int, message, description string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(dbapi.StationDataRateErrorResponse{
+		Err: dbapi.StationDataRateErrorDetailsResponse{Code: code, Message: message, Description: description},
+	})
+}
+
+func writeFailure(writer http.ResponseWriter, status int) {
+	if status == http.StatusTooManyRequests {
+		writeRateError(writer, status, 900802, "Message throttled out",
+			"You have exceeded your quota")
+		return
+	}
+	writeError(writer, status, fmt.Sprintf("%s (injected by dbapitest)", http.StatusText(status)))
+}
@@ -0,0 +1,140 @@
+package dbapitest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	dbapi "github.com/amuttsch/go-db-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_StationFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := NewServer()
+	defer srv.Close()
+
+	s := dbapi.New(Token, dbapi.Config{BaseURL: srv.URL}).StationDataAPI()
+
+	names := func(request dbapi.StationDataStationRequest) []string {
+		resp, err := s.StationByFilter(request)
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, station := range resp.Result {
+			names = append(names, station.Name)
+		}
+		return names
+	}
+
+	assert.Len(names(dbapi.StationDataStationRequest{}), 10)
+	assert.Len(names(dbapi.StationDataStationRequest{Federalstate: "hessen"}), 9)
+	assert.Equal([]string{"Frankfurt (Main) Hbf", "Frankfurt (Main) Süd", "Frankfurt (Main) West"},
+		names(dbapi.StationDataStationRequest{Searchstring: "frankfurt*"}))
+	assert.Equal([]string{"Frankfurt (Main) Hbf"}, names(dbapi.StationDataStationRequest{Category: "1"}))
+	assert.Equal([]string{"Frankfurt (Main) Süd", "Frankfurt (Main) West"},
+		names(dbapi.StationDataStationRequest{Searchstring: "Frankfurt*", Category: "2-3"}))
+	assert.Equal([]string{"Aachen Hbf"}, names(dbapi.StationDataStationRequest{Eva: 8000001}))
+	assert.Equal([]string{"Aachen Hbf"}, names(dbapi.StationDataStationRequest{Ril: "ka"}))
+	assert.Equal([]string{"Aachen Hbf", "Albshausen"},
+		names(dbapi.StationDataStationRequest{Ril: "KA", Category: "6", Logicaloperator: "or"}))
+	assert.Equal([]string{"Frankfurt (Main) Süd"}, names(dbapi.StationDataStationRequest{Searchstring: "Frankfurt*", Offset: 1, Limit: 1}))
+
+	_, err := s.StationByFilter(dbapi.StationDataStationRequest{Searchstring: "Berlin*"})
+	assert.Equal(&dbapi.StationDataErrorResponse{ErrNo: 404, ErrMsg: "Resource not found"}, err)
+}
+
+func TestServer_ByID(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := NewServer()
+	defer srv.Close()
+
+	s := dbapi.New(Token, dbapi.Config{BaseURL: srv.URL}).StationDataAPI()
+
+	stationResp, err := s.StationByID(1866)
+	assert.Nil(err)
+	assert.Equal("Frankfurt (Main) Hbf", stationResp.Result[0].Name)
+
+	_, err = s.StationByID(2)
+	assert.Equal(&dbapi.StationDataErrorResponse{ErrNo: 404, ErrMsg: "Resource not found"}, err)
+
+	szResp, err := s.SZentralenByID(15)
+	assert.Nil(err)
+	assert.Equal("Duisburg Hbf", szResp.Result[0].Name)
+
+	szResp, err = s.SZentralenAll()
+	assert.Nil(err)
+	assert.Equal(30, szResp.Total)
+
+	srv.SetStations([]dbapi.Station{{Number: 2, Name: "Custom"}})
+	stationResp, err = s.StationByID(2)
+	assert.Nil(err)
+	assert.Equal("Custom", stationResp.Result[0].Name)
+}
+
+func TestServer_Credentials(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := NewServer()
+	defer srv.Close()
+
+	_, err := dbapi.New("WrongToken", dbapi.Config{BaseURL: srv.URL}).StationDataAPI().StationByID(1)
+	assert.ErrorContains(err, "Invalid Credentials")
+
+	srv.AddClientID("client", "key")
+	c := dbapi.New("", dbapi.Config{
+		BaseURL:       srv.URL,
+		Authenticator: dbapi.ClientIDAPIKey{ClientID: "client", APIKey: "key"},
+	})
+	_, err = c.StationDataAPI().StationByID(1)
+	assert.Nil(err)
+
+	srv.DisableTokenCheck()
+	_, err = dbapi.New("WrongToken", dbapi.Config{BaseURL: srv.URL}).StationDataAPI().StationByID(1)
+	assert.Nil(err)
+
+	requests := srv.Requests()
+	assert.Len(requests, 3)
+	assert.Equal("/stada/v2/stations/1", requests[0].Path)
+	assert.Equal("Bearer WrongToken", requests[0].Header.Get("Authorization"))
+	assert.Equal("client", requests[1].Header.Get("DB-Client-Id"))
+
+	srv.ResetRequests()
+	assert.Empty(srv.Requests())
+}
+
+func TestServer_Failures(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := NewServer()
+	defer srv.Close()
+
+	s := dbapi.New(Token, dbapi.Config{BaseURL: srv.URL}).StationDataAPI()
+
+	srv.FailNext(http.StatusTooManyRequests, 1)
+	srv.FailNext(http.StatusInternalServerError, 1)
+
+	_, err := s.StationByID(1)
+	assert.Equal(900802, err.(*dbapi.StationDataRateErrorResponse).Err.Code)
+	_, err = s.StationByID(1)
+	assert.Equal(500, err.(*dbapi.StationDataErrorResponse).ErrNo)
+	_, err = s.StationByID(1)
+	assert.Nil(err)
+
+	srv.FailAll(http.StatusNotFound)
+	_, err = s.StationByID(1)
+	assert.NotNil(err)
+	_, err = s.StationByID(1)
+	assert.NotNil(err)
+	srv.ClearFailures()
+
+	srv.SetLatency(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.StationByIDContext(ctx, 1)
+	assert.NotNil(err)
+}
@@ -0,0 +1,271 @@
+package dbapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// departureBoardLookback is how long before the start of a departure board the plans are
+// fetched, so delayed trains planned earlier are shown as well.
+const departureBoardLookback = time.Hour
+
+// Departure is a departure on a DepartureBoard with plan and change data merged.
+type Departure struct {
+	// StopID identifies the stop in the documents of the Timetables API.
+	StopID   string
+	Category string
+	Number   string
+	// Line is the display name of the train, e.g. "S8" or "ICE 1234".
+	Line string
+	// Destination is the last station of the current path, Via the stations before it.
+	Destination string
+	Via         []string
+
+	PlannedTime time.Time
+	// Time is the expected departure time, which equals PlannedTime if there is no delay.
+	Time  time.Time
+	Delay time.Duration
+
+	PlannedPlatform string
+	// Platform is the expected platform, which equals PlannedPlatform if it did not change.
+	Platform string
+
+	Cancelled bool
+	// Added is set for trains that are not part of the plan, e.g. additional trains.
+	Added bool
+
+	// Messages holds the messages of the stop and the departure that have not been revoked,
+	// e.g. causes of delay or disruptions, sorted by timestamp.
+	Messages []TimetableMessage
+}
+
+// PlatformChanged reports whether the train departs from another platform than planned.
+func (d *Departure) PlatformChanged() bool {
+	return d.Platform != d.PlannedPlatform
+}
+
+// DepartureBoard returns the departures from the station with the given eva number that are
+// expected between from and from+duration, sorted by expected departure time. Like a board at
+// the station, it filters on the expected time rather than the planned one: a delayed train
+// planned before from is shown if it departs within the interval, and a train planned within
+// the interval is left out if its delay moves it past the end. Plans are fetched from one hour
+// before from, so trains delayed by more than that may be missing.
+//
+// It fetches the plan of every hour in the interval and applies the full and the recent
+// changes, so the departures carry the actual times, platforms, cancellations and messages.
+// Hours without plan are treated as empty. Stops that are hidden or end at the station are
+// omitted, as are stops only known from the changes, e.g. trains planned before the fetched
+// plans, unless they are additional trains.
+func (t *TimetablesAPI) DepartureBoard(ctx context.Context, eva int, from time.Time, duration time.Duration) ([]Departure, error) {
+	from = from.In(berlin)
+	to := from.Add(duration)
+
+	var documents []*Timetable
+	for hour := from.Add(-departureBoardLookback).Truncate(time.Hour); hour.Before(to); hour = hour.Add(time.Hour) {
+		plan, err := t.PlanContext(ctx, eva, hour, hour.Hour())
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, plan)
+	}
+
+	fullChanges, err := t.FullChangesContext(ctx, eva)
+	if err != nil {
+		return nil, err
+	}
+	recentChanges, err := t.RecentChangesContext(ctx, eva)
+	if err != nil {
+		return nil, err
+	}
+	documents = append(documents, fullChanges, recentChanges)
+
+	var departures []Departure
+	for _, stop := range mergeTimetables(documents...) {
+		dp := stop.Departure
+		if dp == nil || dp.Hidden == 1 || !planned(stop) {
+			continue
+		}
+		departure := newDeparture(stop)
+		if departure.Time.Before(from) || !departure.Time.Before(to) {
+			continue
+		}
+		departures = append(departures, departure)
+	}
+
+	sort.SliceStable(departures, func(i, j int) bool {
+		if !departures[i].Time.Equal(departures[j].Time) {
+			return departures[i].Time.Before(departures[j].Time)
+		}
+		return departures[i].PlannedTime.Before(departures[j].PlannedTime)
+	})
+	return departures, nil
+}
+
+// planned reports whether the departure of stop is part of a fetched plan or an additional
+// train, whose change data carries the planned attributes.
+func planned(stop TimetableStop) bool {
+	dp := stop.Departure
+	if !dp.PlannedTime.IsZero() {
+		return true
+	}
+	added := dp.ChangedStatus == EventAdded || dp.PlannedStatus == EventAdded
+	return added && stop.TripLabel != nil
+}
+
+func newDeparture(stop TimetableStop) Departure {
+	dp := stop.Departure
+
+	departure := Departure{
+		StopID:          stop.ID,
+		PlannedTime:     dp.PlannedTime.Time,
+		Time:            dp.PlannedTime.Time,
+		PlannedPlatform: dp.PlannedPlatform,
+		Platform:        dp.PlannedPlatform,
+		Cancelled:       dp.ChangedStatus == EventCancelled || dp.PlannedStatus == EventCancelled,
+		Added:           dp.ChangedStatus == EventAdded || dp.PlannedStatus == EventAdded,
+		Messages:        mergeMessages(stop.Messages, dp.Messages),
+	}
+	if !dp.ChangedTime.IsZero() {
+		departure.Time = dp.ChangedTime.Time
+		departure.Delay = departure.Time.Sub(departure.PlannedTime)
+	}
+	if dp.ChangedPlatform != "" {
+		departure.Platform = dp.ChangedPlatform
+	}
+
+	if stop.TripLabel != nil {
+		departure.Category = stop.TripLabel.Category
+		departure.Number = stop.TripLabel.Number
+	}
+	if dp.Line != "" {
+		departure.Line = departure.Category + dp.Line
+	} else {
+		departure.Line = departure.Category + " " + departure.Number
+	}
+
+	path := dp.Path()
+	if dp.ChangedPath != "" {
+		path = dp.CurrentPath()
+	}
+	if len(path) > 0 {
+		departure.Destination = path[len(path)-1]
+		departure.Via = path[:len(path)-1]
+	}
+
+	return departure
+}
+
+// mergeTimetables merges plan and change documents by stop id. Later documents override the
+// attributes set by earlier ones, so changes have to be passed after the plans and recent
+// changes after full changes. The stops are returned in order of their first occurrence.
+func mergeTimetables(documents ...*Timetable) []TimetableStop {
+	var stops []TimetableStop
+	index := map[string]int{}
+
+	for _, document := range documents {
+		for _, stop := range document.Stops {
+			i, ok := index[stop.ID]
+			if !ok {
+				index[stop.ID] = len(stops)
+				stops = append(stops, TimetableStop{ID: stop.ID, EVA: stop.EVA})
+				i = len(stops) - 1
+			}
+
+			merged := &stops[i]
+			if stop.TripLabel != nil {
+				merged.TripLabel = stop.TripLabel
+			}
+			merged.Arrival = mergeEvent(merged.Arrival, stop.Arrival)
+			merged.Departure = mergeEvent(merged.Departure, stop.Departure)
+			merged.Messages = mergeMessages(merged.Messages, stop.Messages)
+		}
+	}
+	return stops
+}
+
+// mergeEvent applies all attributes set in change to a copy of event.
+func mergeEvent(event, change *TimetableEvent) *TimetableEvent {
+	if change == nil {
+		return event
+	}
+	if event == nil {
+		event = &TimetableEvent{}
+	}
+
+	merged := *event
+	setTime := func(dst *TimetableTime, src TimetableTime) {
+		if !src.IsZero() {
+			*dst = src
+		}
+	}
+	setString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	setTime(&merged.PlannedTime, change.PlannedTime)
+	setTime(&merged.ChangedTime, change.ChangedTime)
+	setTime(&merged.CancellationTime, change.CancellationTime)
+	setString(&merged.PlannedPlatform, change.PlannedPlatform)
+	setString(&merged.ChangedPlatform, change.ChangedPlatform)
+	setString(&merged.PlannedPath, change.PlannedPath)
+	setString(&merged.ChangedPath, change.ChangedPath)
+	setString(&merged.Line, change.Line)
+	setString(&merged.Wings, change.Wings)
+	setString(&merged.Transition, change.Transition)
+	setString(&merged.PlannedDistantEndpoint, change.PlannedDistantEndpoint)
+	setString(&merged.ChangedDistantEndpoint, change.ChangedDistantEndpoint)
+	if change.PlannedStatus != "" {
+		merged.PlannedStatus = change.PlannedStatus
+	}
+	if change.ChangedStatus != "" {
+		merged.ChangedStatus = change.ChangedStatus
+	}
+	if change.Hidden != 0 {
+		merged.Hidden = change.Hidden
+	}
+	merged.Messages = mergeMessages(merged.Messages, change.Messages)
+
+	return &merged
+}
+
+// mergeMessages combines the message lists, with later messages replacing earlier ones with
+// the same id. Revoked messages are dropped and the result is sorted by timestamp.
+func mergeMessages(lists ...[]TimetableMessage) []TimetableMessage {
+	var merged []TimetableMessage
+	index := map[string]int{}
+
+	for _, list := range lists {
+		for _, message := range list {
+			if i, ok := index[message.ID]; ok && message.ID != "" {
+				merged[i] = message
+				continue
+			}
+			index[message.ID] = len(merged)
+			merged = append(merged, message)
+		}
+	}
+
+	result := merged[:0]
+	for _, message := range merged {
+		if message.Deleted != 1 {
+			result = append(result, message)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp.Time)
+	})
+	return result
+}
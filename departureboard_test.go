@@ -0,0 +1,102 @@
+package dbapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimetablesAPI_DepartureBoard(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	tt := c.TimetablesAPI()
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 7, 14, hour, minute, 0, 0, berlin)
+	}
+
+	// There is no plan for 11 and 13 o'clock. The train planned at 10:20 and delayed to 12:20
+	// is only part of the changes and omitted.
+	departures, err := tt.DepartureBoard(context.Background(), 8000105, at(12, 0), 90*time.Minute)
+	assert.Nil(err)
+
+	lines := []string{}
+	for _, departure := range departures {
+		lines = append(lines, departure.Line)
+	}
+	assert.Equal([]string{"ICE 1234", "S8", "RE30", "ICE 9999", "RB58"}, lines)
+	for _, departure := range departures {
+		assert.False(departure.PlannedTime.IsZero())
+	}
+
+	ice := departures[0]
+	assert.Equal(at(12, 4), ice.PlannedTime)
+	assert.Equal(at(12, 11), ice.Time)
+	assert.Equal(7*time.Minute, ice.Delay)
+	assert.Equal("7", ice.PlannedPlatform)
+	assert.Equal("8", ice.Platform)
+	assert.True(ice.PlatformChanged())
+	assert.Equal("Basel SBB", ice.Destination)
+	assert.Equal([]string{"Mannheim Hbf", "Karlsruhe Hbf"}, ice.Via)
+	assert.Len(ice.Messages, 2)
+	assert.Equal(43, ice.Messages[0].Code)
+
+	s8 := departures[1]
+	assert.Equal(time.Duration(0), s8.Delay)
+	assert.False(s8.PlatformChanged())
+	assert.Equal("Hanau Hbf", s8.Destination)
+	assert.Nil(s8.Messages)
+
+	re := departures[2]
+	assert.True(re.Cancelled)
+	assert.Equal(at(12, 32), re.Time)
+	assert.Equal("Zug fällt aus", re.Messages[0].ExternalText)
+
+	added := departures[3]
+	assert.True(added.Added)
+	assert.Equal("München Hbf", added.Destination)
+
+	// Recent changes override full changes
+	rb := departures[4]
+	assert.Equal(at(13, 0), rb.Time)
+	assert.Equal(5*time.Minute, rb.Delay)
+	assert.Equal(80, rb.Messages[0].Code)
+
+	departures, err = tt.DepartureBoard(context.Background(), 8000105, at(12, 30), 15*time.Minute)
+	assert.Nil(err)
+	assert.Len(departures, 2)
+
+	// Delayed trains planned before from are shown, trains delayed past the end are not
+	departures, err = tt.DepartureBoard(context.Background(), 8000105, at(12, 5), 10*time.Minute)
+	assert.Nil(err)
+	assert.Len(departures, 2)
+	assert.Equal("ICE 1234", departures[0].Line)
+	departures, err = tt.DepartureBoard(context.Background(), 8000105, at(12, 30), 30*time.Minute)
+	assert.Nil(err)
+	assert.Len(departures, 2)
+
+	_, err = tt.DepartureBoard(context.Background(), 8000106, at(12, 30), time.Hour)
+	assert.Equal(&APIError{API: "timetables", StatusCode: 404}, err)
+}
+
+func TestMergeMessages(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := func(minute int) TimetableTime {
+		return TimetableTime{time.Date(2022, 7, 14, 12, minute, 0, 0, berlin)}
+	}
+
+	merged := mergeMessages(
+		[]TimetableMessage{{ID: "1", Code: 43, Timestamp: ts(5)}, {ID: "2", Code: 80, Timestamp: ts(1)}},
+		[]TimetableMessage{{ID: "1", Code: 44, Timestamp: ts(6)}, {ID: "3", Timestamp: ts(2), Deleted: 1}},
+	)
+	assert.Equal([]TimetableMessage{{ID: "2", Code: 80, Timestamp: ts(1)}, {ID: "1", Code: 44, Timestamp: ts(6)}}, merged)
+	assert.Nil(mergeMessages(nil, []TimetableMessage{{ID: "1", Deleted: 1}}))
+}
@@ -0,0 +1,54 @@
+package dbapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned by the APIs added after the StationData API if a request fails with a
+// status other than 429. Rate limited requests return a *StationDataRateErrorResponse, as
+// the rate limit is enforced by the API gateway shared by all APIs.
+type APIError struct {
+	// API names the API, e.g. "timetables".
+	API        string
+	StatusCode int
+	// Message is the body of the response.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s: error %d: %s", e.API, e.StatusCode, message)
+}
+
+// decodeResponse decodes a successful response into data with unmarshal and converts
+// all other responses into errors.
+func decodeResponse(api string, resp *response, data interface{}, unmarshal func([]byte, interface{}) error) error {
+	switch resp.statusCode {
+	case http.StatusOK:
+		if err := unmarshal(resp.body, data); err != nil {
+			return err
+		}
+		if resp.stale {
+			if marker, ok := data.(staleMarker); ok {
+				marker.markStale(resp.age)
+			}
+		}
+		return nil
+	case http.StatusTooManyRequests:
+		rateErrorResponse := StationDataRateErrorResponse{}
+		if err := json.Unmarshal(resp.body, &rateErrorResponse); err == nil && rateErrorResponse.Err.Code != 0 {
+			return &rateErrorResponse
+		}
+	}
+	return &APIError{
+		API:        api,
+		StatusCode: resp.statusCode,
+		Message:    strings.TrimSpace(string(resp.body)),
+	}
+}
@@ -0,0 +1,30 @@
+package dbapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	data := &StationDataStationResponse{}
+	err := decodeResponse("test", &response{statusCode: 200, body: []byte(`{"total":1}`), stale: true}, data, json.Unmarshal)
+	assert.Nil(err)
+	assert.Equal(1, data.Total)
+	assert.True(data.Stale)
+
+	err = decodeResponse("test", &response{
+		statusCode: 429,
+		body:       []byte(`{"error":{"code":900802,"message":"Message throttled out"}}`),
+	}, data, json.Unmarshal)
+	assert.Equal(&StationDataRateErrorResponse{Err: StationDataRateErrorDetailsResponse{Code: 900802, Message: "Message throttled out"}}, err)
+
+	err = decodeResponse("test", &response{statusCode: 429, body: []byte("Too many requests\n")}, data, json.Unmarshal)
+	assert.Equal(&APIError{API: "test", StatusCode: 429, Message: "Too many requests"}, err)
+
+	err = decodeResponse("test", &response{statusCode: 500}, data, json.Unmarshal)
+	assert.EqualError(err, "test: error 500: Internal Server Error")
+}
@@ -0,0 +1,373 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const fahrplanAPIPath = "/fahrplan-plus/v1"
+
+// fahrplanAPIName identifies the Fahrplan API in instrumentation events.
+const fahrplanAPIName = "fahrplan"
+
+// Endpoint templates of the Fahrplan API.
+const (
+	FahrplanLocationEndpoint       = fahrplanAPIPath + "/location/{name}"
+	FahrplanDepartureBoardEndpoint = fahrplanAPIPath + "/departureBoard/{id}"
+	FahrplanArrivalBoardEndpoint   = fahrplanAPIPath + "/arrivalBoard/{id}"
+	FahrplanJourneyDetailsEndpoint = fahrplanAPIPath + "/journeyDetails/{id}"
+)
+
+const (
+	fahrplanDateTimeLayout = "2006-01-02T15:04"
+	fahrplanTimeLayout     = "15:04"
+)
+
+// FahrplanConfig provides configuration options for the Fahrplan API. Set RateLimitPerMinute to
+// zero if you want to disable rate limiting done in the library.
+type FahrplanConfig struct {
+	RateLimitPerMinute int
+}
+
+// Location is a station or stop found by FahrplanAPI.Locations.
+type Location struct {
+	Name string  `json:"name"`
+	Lon  float64 `json:"lon"`
+	Lat  float64 `json:"lat"`
+	// ID is the eva number of the location, to be used for the boards.
+	ID int `json:"id"`
+}
+
+// FahrplanBoardRequest is used to query the departure and arrival boards of a location. If Date
+// is not set, the board starts now.
+type FahrplanBoardRequest struct {
+	LocationID int
+	Date       time.Time
+}
+
+// BoardEntry is a train on a departure or arrival board.
+type BoardEntry struct {
+	// Name of the train, e.g. "ICE 1234".
+	Name string
+	// Type is the category of the train, e.g. "ICE".
+	Type     string
+	BoardID  int
+	StopID   int
+	StopName string
+	// DateTime is the planned departure or arrival at the stop.
+	DateTime time.Time
+	Track    string
+	// Origin is the first station of the train, only set on arrival boards.
+	Origin string
+	// JourneyID identifies the journey of the train for FahrplanAPI.JourneyDetails.
+	JourneyID JourneyID
+}
+
+type rawBoardEntry struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	BoardID   int    `json:"boardId"`
+	StopID    int    `json:"stopId"`
+	StopName  string `json:"stopName"`
+	DateTime  string `json:"dateTime"`
+	Track     string `json:"track"`
+	Origin    string `json:"origin"`
+	DetailsID string `json:"detailsId"`
+}
+
+// JourneyID is an opaque token identifying a journey. It is taken from a BoardEntry and
+// remembers the date of the board, as the journey details only contain times of day.
+type JourneyID struct {
+	id       string
+	stopID   int
+	dateTime time.Time
+}
+
+// NewJourneyID creates a JourneyID from a detailsId returned by the API, which is passed to
+// the API unchanged. date is the day the journey stops at the first stop.
+func NewJourneyID(detailsID string, date time.Time) JourneyID {
+	return JourneyID{id: detailsID, dateTime: date}
+}
+
+// String returns the detailsId of the journey.
+func (j JourneyID) String() string {
+	return j.id
+}
+
+// JourneyStop is a stop of a journey.
+type JourneyStop struct {
+	StopID   int
+	StopName string
+	Lat      float64
+	Lon      float64
+	// ArrivalTime is zero at the first stop, DepartureTime at the last one.
+	ArrivalTime   time.Time
+	DepartureTime time.Time
+	Track         string
+	// Train is the name of the train, e.g. "ICE 1234".
+	Train    string
+	Type     string
+	Operator string
+	Notes    []JourneyNote
+}
+
+// JourneyNote is a note about a stop of a journey, e.g. "Bordrestaurant".
+type JourneyNote struct {
+	Key      string `json:"key"`
+	Priority int    `json:"priority"`
+	Text     string `json:"text"`
+}
+
+type rawJourneyStop struct {
+	StopID   int             `json:"stopId"`
+	StopName string          `json:"stopName"`
+	Lat      json.RawMessage `json:"lat"`
+	Lon      json.RawMessage `json:"lon"`
+	ArrTime  string          `json:"arrTime"`
+	DepTime  string          `json:"depTime"`
+	Track    string          `json:"track"`
+	Train    string          `json:"train"`
+	Type     string          `json:"type"`
+	Operator string          `json:"operator"`
+	Notes    []JourneyNote   `json:"notes"`
+}
+
+// FahrplanAPI is a struct holding internal information about this API. Its methods can be used
+// to query the API.
+type FahrplanAPI struct {
+	client *Client
+}
+
+// Locations returns the stations and stops matching name.
+func (f *FahrplanAPI) Locations(name string) ([]Location, error) {
+	return f.LocationsContext(context.Background(), name)
+}
+
+// LocationsContext is like Locations but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (f *FahrplanAPI) LocationsContext(ctx context.Context, name string) ([]Location, error) {
+	url := fmt.Sprintf("%s%s/location/%s", f.client.baseURL(), fahrplanAPIPath, url.PathEscape(name))
+
+	var locations []Location
+	err := f.get(ctx, &call{
+		operation:  "Locations",
+		endpoint:   FahrplanLocationEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.String("dbapi.fahrplan.location", name)},
+	}, &locations)
+	return locations, err
+}
+
+// DepartureBoard returns the next departures at a location.
+func (f *FahrplanAPI) DepartureBoard(boardRequest FahrplanBoardRequest) ([]BoardEntry, error) {
+	return f.DepartureBoardContext(context.Background(), boardRequest)
+}
+
+// DepartureBoardContext is like DepartureBoard but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (f *FahrplanAPI) DepartureBoardContext(ctx context.Context, boardRequest FahrplanBoardRequest) ([]BoardEntry, error) {
+	return f.board(ctx, "DepartureBoard", "departureBoard", FahrplanDepartureBoardEndpoint, boardRequest)
+}
+
+// ArrivalBoard returns the next arrivals at a location.
+func (f *FahrplanAPI) ArrivalBoard(boardRequest FahrplanBoardRequest) ([]BoardEntry, error) {
+	return f.ArrivalBoardContext(context.Background(), boardRequest)
+}
+
+// ArrivalBoardContext is like ArrivalBoard but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (f *FahrplanAPI) ArrivalBoardContext(ctx context.Context, boardRequest FahrplanBoardRequest) ([]BoardEntry, error) {
+	return f.board(ctx, "ArrivalBoard", "arrivalBoard", FahrplanArrivalBoardEndpoint, boardRequest)
+}
+
+// JourneyDetails returns all stops of the journey identified by id.
+func (f *FahrplanAPI) JourneyDetails(id JourneyID) ([]JourneyStop, error) {
+	return f.JourneyDetailsContext(context.Background(), id)
+}
+
+// JourneyDetailsContext is like JourneyDetails but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (f *FahrplanAPI) JourneyDetailsContext(ctx context.Context, id JourneyID) ([]JourneyStop, error) {
+	url := fmt.Sprintf("%s%s/journeyDetails/%s", f.client.baseURL(), fahrplanAPIPath, id.id)
+
+	var raw []rawJourneyStop
+	err := f.get(ctx, &call{
+		operation: "JourneyDetails",
+		endpoint:  FahrplanJourneyDetailsEndpoint,
+		url:       url,
+	}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	return newJourneyStops(raw, id)
+}
+
+func (f *FahrplanAPI) board(ctx context.Context, operation, path, endpoint string, boardRequest FahrplanBoardRequest) ([]BoardEntry, error) {
+	date := boardRequest.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	date = date.In(berlin)
+
+	q := url.Values{"date": {date.Format(fahrplanDateTimeLayout)}}
+	url := fmt.Sprintf("%s%s/%s/%d?%s", f.client.baseURL(), fahrplanAPIPath, path, boardRequest.LocationID, q.Encode())
+
+	var raw []rawBoardEntry
+	err := f.get(ctx, &call{
+		operation:  operation,
+		endpoint:   endpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.fahrplan.location_id", boardRequest.LocationID)},
+	}, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]BoardEntry, 0, len(raw))
+	for _, r := range raw {
+		dateTime, err := time.ParseInLocation(fahrplanDateTimeLayout, r.DateTime, berlin)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, BoardEntry{
+			Name:      r.Name,
+			Type:      r.Type,
+			BoardID:   r.BoardID,
+			StopID:    r.StopID,
+			StopName:  r.StopName,
+			DateTime:  dateTime,
+			Track:     r.Track,
+			Origin:    r.Origin,
+			JourneyID: JourneyID{id: r.DetailsID, stopID: r.StopID, dateTime: dateTime},
+		})
+	}
+	return entries, nil
+}
+
+func (f *FahrplanAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = fahrplanAPIName
+	c.rateLimitPerMinute = f.client.apiConfig.FahrplanConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := f.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(fahrplanAPIName, resp, data, json.Unmarshal)
+}
+
+// newJourneyStops converts the stops and resolves their times of day to dates. The time at
+// the stop the JourneyID was taken from is anchored at the date of the board; a time of day
+// earlier than the one before means the journey passed midnight.
+func newJourneyStops(raw []rawJourneyStop, id JourneyID) ([]JourneyStop, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	anchorDate := id.dateTime
+	if anchorDate.IsZero() {
+		anchorDate = time.Now()
+	}
+	anchorDate = anchorDate.In(berlin)
+	boardMinutes := anchorDate.Hour()*60 + anchorDate.Minute()
+
+	// times holds the arrival and departure of every stop in order, in minutes of the day
+	// or -1 if not set
+	times := make([]int, 2*len(raw))
+	anchor := -1
+	for i, r := range raw {
+		for j, value := range []string{r.ArrTime, r.DepTime} {
+			times[2*i+j] = -1
+			if value == "" {
+				continue
+			}
+			t, err := time.Parse(fahrplanTimeLayout, value)
+			if err != nil {
+				return nil, err
+			}
+			times[2*i+j] = t.Hour()*60 + t.Minute()
+			if id.stopID != 0 && r.StopID == id.stopID && times[2*i+j] == boardMinutes {
+				anchor = 2*i + j
+			}
+		}
+	}
+	if anchor < 0 {
+		// Without a matching board entry, the first time is on the given date
+		anchor = 0
+		for anchor < len(times)-1 && times[anchor] < 0 {
+			anchor++
+		}
+	}
+
+	days := make([]int, len(times))
+	for i, last := anchor+1, times[anchor]; i < len(times); i++ {
+		days[i] = days[i-1]
+		if times[i] < 0 {
+			continue
+		}
+		if last >= 0 && times[i] < last {
+			days[i]++
+		}
+		last = times[i]
+	}
+	for i, next := anchor-1, times[anchor]; i >= 0; i-- {
+		days[i] = days[i+1]
+		if times[i] < 0 {
+			continue
+		}
+		if next >= 0 && times[i] > next {
+			days[i]--
+		}
+		next = times[i]
+	}
+
+	year, month, day := anchorDate.Date()
+	toTime := func(i int) time.Time {
+		if times[i] < 0 {
+			return time.Time{}
+		}
+		return time.Date(year, month, day+days[i], times[i]/60, times[i]%60, 0, 0, berlin)
+	}
+
+	stops := make([]JourneyStop, 0, len(raw))
+	for i, r := range raw {
+		lat, err := parseCoordinate(r.Lat)
+		if err != nil {
+			return nil, err
+		}
+		lon, err := parseCoordinate(r.Lon)
+		if err != nil {
+			return nil, err
+		}
+		stops = append(stops, JourneyStop{
+			StopID:        r.StopID,
+			StopName:      r.StopName,
+			Lat:           lat,
+			Lon:           lon,
+			ArrivalTime:   toTime(2 * i),
+			DepartureTime: toTime(2*i + 1),
+			Track:         r.Track,
+			Train:         r.Train,
+			Type:          r.Type,
+			Operator:      r.Operator,
+			Notes:         r.Notes,
+		})
+	}
+	return stops, nil
+}
+
+// parseCoordinate accepts coordinates sent as JSON number or string.
+func parseCoordinate(raw json.RawMessage) (float64, error) {
+	value := strings.Trim(string(raw), `"`)
+	if value == "" || value == "null" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
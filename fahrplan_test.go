@@ -0,0 +1,124 @@
+package dbapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testJourneyDetailsID = "835809%2F280406%2F441054%2F175268%2F80%3fstation_evaId%3D8000105"
+
+func init() {
+	serveTestdata("/fahrplan-plus/v1/", ".json", "")
+
+	// Journey details ids are opaque and must be sent as is
+	http.HandleFunc("/fahrplan-plus/v1/journeyDetails/", func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.EscapedPath() != "/fahrplan-plus/v1/journeyDetails/"+testJourneyDetailsID {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeFile(writer, request, "testdata/fahrplan-plus/v1/journeyDetails.json")
+	})
+}
+
+func TestFahrplanAPI_Locations(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	f := c.FahrplanAPI()
+
+	locations, err := f.Locations("Frankfurt")
+	assert.Nil(err)
+	assert.Len(locations, 3)
+	assert.Equal(Location{Name: "Frankfurt(Main)Hbf", Lon: 8.663785, Lat: 50.107149, ID: 8000105}, locations[0])
+
+	_, err = f.Locations("Nowhere")
+	assert.Equal(&APIError{API: "fahrplan", StatusCode: 404}, err)
+}
+
+func TestFahrplanAPI_Boards(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var query string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{captureQuery(&query)},
+	})
+	f := c.FahrplanAPI()
+
+	date := time.Date(2017, 7, 14, 21, 0, 0, 0, time.UTC)
+	departures, err := f.DepartureBoard(FahrplanBoardRequest{LocationID: 8000105, Date: date})
+	assert.Nil(err)
+	assert.Equal("date=2017-07-14T23%3A00", query)
+	assert.Len(departures, 2)
+
+	ice := departures[0]
+	assert.Equal("ICE 1091", ice.Name)
+	assert.Equal("ICE", ice.Type)
+	assert.Equal("9", ice.Track)
+	assert.Equal(time.Date(2017, 7, 14, 23, 20, 0, 0, berlin), ice.DateTime)
+	assert.Equal(testJourneyDetailsID, ice.JourneyID.String())
+
+	arrivals, err := f.ArrivalBoard(FahrplanBoardRequest{LocationID: 8000105, Date: date})
+	assert.Nil(err)
+	assert.Equal("Berlin Hbf", arrivals[0].Origin)
+}
+
+func TestFahrplanAPI_JourneyDetails(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	f := c.FahrplanAPI()
+
+	departures, err := f.DepartureBoard(FahrplanBoardRequest{LocationID: 8000105})
+	assert.Nil(err)
+
+	stops, err := f.JourneyDetails(departures[0].JourneyID)
+	assert.Nil(err)
+	assert.Len(stops, 5)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2017, 7, day, hour, minute, 0, 0, berlin)
+	}
+
+	assert.Equal("Berlin Hbf", stops[0].StopName)
+	assert.Equal(52.525589, stops[0].Lat)
+	assert.True(stops[0].ArrivalTime.IsZero())
+	assert.Equal(at(14, 19, 30), stops[0].DepartureTime)
+	assert.Equal([]JourneyNote{{Key: "BR", Priority: 450, Text: "Bordrestaurant"}}, stops[0].Notes)
+
+	assert.Equal(at(14, 23, 20), stops[2].DepartureTime)
+	assert.Equal(at(14, 23, 58), stops[3].ArrivalTime)
+	assert.Equal(at(15, 0, 2), stops[3].DepartureTime)
+	assert.Equal(at(15, 3, 10), stops[4].ArrivalTime)
+	assert.True(stops[4].DepartureTime.IsZero())
+
+	// Arrival boards anchor the journey at the arrival
+	arrivals, err := f.ArrivalBoard(FahrplanBoardRequest{LocationID: 8000105})
+	assert.Nil(err)
+	stops, err = f.JourneyDetails(arrivals[0].JourneyID)
+	assert.Nil(err)
+	assert.Equal(at(14, 19, 30), stops[0].DepartureTime)
+
+	// Without a board entry, the first stop is on the given date
+	stops, err = f.JourneyDetails(NewJourneyID(testJourneyDetailsID, at(14, 0, 0)))
+	assert.Nil(err)
+	assert.Equal(at(14, 19, 30), stops[0].DepartureTime)
+	assert.Equal(at(15, 3, 10), stops[4].ArrivalTime)
+
+	_, err = f.JourneyDetails(NewJourneyID("unknown", at(14, 0, 0)))
+	assert.IsType(&APIError{}, err)
+}
@@ -0,0 +1,169 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-querystring/query"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const fastaAPIPath = "/fasta/v2"
+
+// fastaAPIName identifies the FaSta API in instrumentation events.
+const fastaAPIName = "fasta"
+
+// Endpoint templates of the FaSta API.
+const (
+	FaStaFacilitiesEndpoint = fastaAPIPath + "/facilities"
+	FaStaFacilityEndpoint   = fastaAPIPath + "/facilities/{equipmentnumber}"
+	FaStaStationEndpoint    = fastaAPIPath + "/stations/{stationnumber}"
+)
+
+// FaStaConfig provides configuration options for the FaSta API. Set RateLimitPerMinute to zero
+// if you want to disable rate limiting done in the library.
+type FaStaConfig struct {
+	RateLimitPerMinute int
+}
+
+// FacilityType is the type of a Facility.
+type FacilityType string
+
+// Facility types of the FaSta API.
+const (
+	FacilityElevator  FacilityType = "ELEVATOR"
+	FacilityEscalator FacilityType = "ESCALATOR"
+)
+
+// FacilityState is the operational state of a Facility.
+type FacilityState string
+
+// Facility states of the FaSta API.
+const (
+	FacilityActive   FacilityState = "ACTIVE"
+	FacilityInactive FacilityState = "INACTIVE"
+	FacilityUnknown  FacilityState = "UNKNOWN"
+)
+
+// Facility is an elevator or escalator at a station. StationNumber refers to Station.Number of
+// the StationData API.
+type Facility struct {
+	EquipmentNumber int          `json:"equipmentnumber"`
+	Type            FacilityType `json:"type"`
+	Description     string       `json:"description,omitempty"`
+	// GeocoordX is the longitude and GeocoordY the latitude of the facility.
+	GeocoordX        float64       `json:"geocoordX,omitempty"`
+	GeocoordY        float64       `json:"geocoordY,omitempty"`
+	State            FacilityState `json:"state"`
+	StateExplanation string        `json:"stateExplanation,omitempty"`
+	StationNumber    int           `json:"stationnumber"`
+	OperatorName     string        `json:"operatorname,omitempty"`
+}
+
+// FaStaStation holds all facilities of a station.
+type FaStaStation struct {
+	StationNumber int        `json:"stationnumber"`
+	Name          string     `json:"name"`
+	Facilities    []Facility `json:"facilities"`
+}
+
+// FaStaFacilitiesRequest is used by Facilities to filter the facilities. If it's not changed,
+// all facilities are queried.
+type FaStaFacilitiesRequest struct {
+	Type             []FacilityType  `url:"type,comma,omitempty"`
+	State            []FacilityState `url:"state,comma,omitempty"`
+	EquipmentNumbers []int           `url:"equipmentnumbers,comma,omitempty"`
+	StationNumber    int             `url:"stationnumber,omitempty"`
+}
+
+// FaStaAPI is a struct holding internal information about this API. Its methods can be used
+// to query the API. As the states of the facilities change any time, responses are never
+// served from the cache.
+type FaStaAPI struct {
+	client *Client
+}
+
+// Facilities returns the facilities matching the filter.
+func (f *FaStaAPI) Facilities(facilitiesRequest FaStaFacilitiesRequest) ([]Facility, error) {
+	return f.FacilitiesContext(context.Background(), facilitiesRequest)
+}
+
+// FacilitiesContext is like Facilities but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (f *FaStaAPI) FacilitiesContext(ctx context.Context, facilitiesRequest FaStaFacilitiesRequest) ([]Facility, error) {
+	q, err := query.Values(facilitiesRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s%s/facilities", f.client.baseURL(), fastaAPIPath)
+	if len(q) > 0 {
+		url += "?" + q.Encode()
+	}
+
+	var facilities []Facility
+	err = f.get(ctx, &call{
+		operation:  "Facilities",
+		noCache:    true,
+		endpoint:   FaStaFacilitiesEndpoint,
+		url:        url,
+		attributes: filterAttributes(q),
+	}, &facilities)
+	return facilities, err
+}
+
+// FacilityByEquipmentNumber returns the facility with the given equipment number.
+func (f *FaStaAPI) FacilityByEquipmentNumber(equipmentNumber int) (*Facility, error) {
+	return f.FacilityByEquipmentNumberContext(context.Background(), equipmentNumber)
+}
+
+// FacilityByEquipmentNumberContext is like FacilityByEquipmentNumber but aborts waiting for the
+// rate limiter and the request once ctx is done.
+func (f *FaStaAPI) FacilityByEquipmentNumberContext(ctx context.Context, equipmentNumber int) (*Facility, error) {
+	url := fmt.Sprintf("%s%s/facilities/%d", f.client.baseURL(), fastaAPIPath, equipmentNumber)
+
+	facility := &Facility{}
+	err := f.get(ctx, &call{
+		operation:  "FacilityByEquipmentNumber",
+		noCache:    true,
+		endpoint:   FaStaFacilityEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.fasta.equipmentnumber", equipmentNumber)},
+	}, facility)
+	return facility, err
+}
+
+// StationFacilities returns all facilities of the station with the given number, which is the
+// Station.Number of the StationData API.
+func (f *FaStaAPI) StationFacilities(stationNumber int) (*FaStaStation, error) {
+	return f.StationFacilitiesContext(context.Background(), stationNumber)
+}
+
+// StationFacilitiesContext is like StationFacilities but aborts waiting for the rate limiter
+// and the request once ctx is done.
+func (f *FaStaAPI) StationFacilitiesContext(ctx context.Context, stationNumber int) (*FaStaStation, error) {
+	url := fmt.Sprintf("%s%s/stations/%d", f.client.baseURL(), fastaAPIPath, stationNumber)
+
+	station := &FaStaStation{}
+	err := f.get(ctx, &call{
+		operation:  "StationFacilities",
+		noCache:    true,
+		endpoint:   FaStaStationEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.station.id", stationNumber)},
+	}, station)
+	return station, err
+}
+
+func (f *FaStaAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = fastaAPIName
+	c.rateLimitPerMinute = f.client.apiConfig.FaStaConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := f.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(fastaAPIName, resp, data, json.Unmarshal)
+}
@@ -0,0 +1,85 @@
+package dbapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/fasta/v2/", ".json", `{"errorCode":404,"errorMessage":"Not Found"}`)
+}
+
+func TestFaStaAPI_Facilities(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var query string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{captureQuery(&query)},
+	})
+	f := c.FaStaAPI()
+
+	facilities, err := f.Facilities(FaStaFacilitiesRequest{})
+	assert.Nil(err)
+	assert.Equal("", query)
+	assert.Len(facilities, 4)
+	assert.Equal(Facility{
+		EquipmentNumber:  10466938,
+		Type:             FacilityElevator,
+		Description:      "zu Gleis 7/8",
+		GeocoordX:        8.6621541,
+		GeocoordY:        50.1066872,
+		State:            FacilityInactive,
+		StateExplanation: "under maintenance",
+		StationNumber:    1866,
+		OperatorName:     "DB InfraGO",
+	}, facilities[0])
+
+	_, err = f.Facilities(FaStaFacilitiesRequest{
+		Type:             []FacilityType{FacilityElevator, FacilityEscalator},
+		State:            []FacilityState{FacilityInactive},
+		EquipmentNumbers: []int{10466938, 10466939},
+	})
+	assert.Nil(err)
+	assert.Equal("equipmentnumbers=10466938%2C10466939&state=INACTIVE&type=ELEVATOR%2CESCALATOR", query)
+}
+
+func TestFaStaAPI_FacilityByEquipmentNumber(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	f := c.FaStaAPI()
+
+	facility, err := f.FacilityByEquipmentNumber(10466938)
+	assert.Nil(err)
+	assert.Equal(FacilityInactive, facility.State)
+	assert.Equal(1866, facility.StationNumber)
+
+	_, err = f.FacilityByEquipmentNumber(1)
+	assert.Equal(&APIError{API: "fasta", StatusCode: 404, Message: `{"errorCode":404,"errorMessage":"Not Found"}`}, err)
+}
+
+func TestFaStaAPI_StationFacilities(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+
+	station, err := c.FaStaAPI().StationFacilities(1866)
+	assert.Nil(err)
+	assert.Equal("Frankfurt (Main) Hbf", station.Name)
+	assert.Len(station.Facilities, 3)
+	assert.Equal(FacilityEscalator, station.Facilities[2].Type)
+	assert.Equal(FacilityUnknown, station.Facilities[2].State)
+}
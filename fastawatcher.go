@@ -0,0 +1,181 @@
+package dbapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultWatchInterval = time.Minute
+
+// FacilityWatcherConfig configures a FacilityWatcher.
+type FacilityWatcherConfig struct {
+	// Request filters the polled facilities, e.g. to elevators of some stations.
+	Request FaStaFacilitiesRequest
+	// Interval between two polls, defaults to one minute.
+	Interval time.Duration
+	// Debounce is the time a new state has to persist before the transition is reported, so
+	// facilities flapping between states do not cause a flood of events. Zero reports every
+	// transition at the poll it is detected.
+	Debounce time.Duration
+	// Stations is used to look up the station of a facility. It defaults to the StationDataAPI
+	// of the Client.
+	Stations StationDataService
+	// OnError is called with errors of polls and station lookups if set. The watcher keeps
+	// running after errors.
+	OnError func(err error)
+}
+
+// FacilityEvent reports that a facility changed its state.
+type FacilityEvent struct {
+	Facility Facility
+	Previous FacilityState
+	State    FacilityState
+	// Station is the station of the facility, nil if it could not be looked up.
+	Station *Station
+	// Time is the time of the poll that detected the change.
+	Time time.Time
+}
+
+// FacilityWatcher polls the FaSta API and reports facilities changing their state, e.g.
+// elevators going out of service and coming back. The first poll establishes the known states
+// without reporting them. Facilities reporting FacilityUnknown keep their last known state, as
+// UNKNOWN means the monitoring of the facility is disrupted.
+type FacilityWatcher struct {
+	api      *FaStaAPI
+	cfg      FacilityWatcherConfig
+	stations StationDataService
+
+	mu           sync.Mutex
+	facilities   map[int]*watchedFacility
+	stationCache map[int]*Station
+}
+
+type watchedFacility struct {
+	reported     FacilityState
+	pending      FacilityState
+	pendingSince time.Time
+}
+
+// NewFacilityWatcher creates a FacilityWatcher polling api.
+func NewFacilityWatcher(api *FaStaAPI, cfg FacilityWatcherConfig) *FacilityWatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultWatchInterval
+	}
+	stations := cfg.Stations
+	if stations == nil {
+		stations = api.client.StationDataAPI()
+	}
+
+	return &FacilityWatcher{
+		api:          api,
+		cfg:          cfg,
+		stations:     stations,
+		facilities:   map[int]*watchedFacility{},
+		stationCache: map[int]*Station{},
+	}
+}
+
+// Run polls the API until ctx is done and calls handle for every state change, from the
+// goroutine calling Run. It returns ctx.Err().
+func (w *FacilityWatcher) Run(ctx context.Context, handle func(FacilityEvent)) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, event := range w.poll(ctx, time.Now()) {
+			handle(event)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the facilities once and returns the state changes detected at now.
+func (w *FacilityWatcher) poll(ctx context.Context, now time.Time) []FacilityEvent {
+	facilities, err := w.api.FacilitiesContext(ctx, w.cfg.Request)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.reportError(err)
+		}
+		return nil
+	}
+
+	w.mu.Lock()
+	var changed []FacilityEvent
+	for _, facility := range facilities {
+		state := facility.State
+		watched, known := w.facilities[facility.EquipmentNumber]
+		if !known {
+			if state != FacilityUnknown {
+				w.facilities[facility.EquipmentNumber] = &watchedFacility{reported: state}
+			}
+			continue
+		}
+		if state == FacilityUnknown {
+			continue
+		}
+		if state == watched.reported {
+			// Flapped back before the change was reported
+			watched.pending = ""
+			continue
+		}
+
+		if state != watched.pending {
+			watched.pending = state
+			watched.pendingSince = now
+		}
+		if now.Sub(watched.pendingSince) < w.cfg.Debounce {
+			continue
+		}
+
+		changed = append(changed, FacilityEvent{
+			Facility: facility,
+			Previous: watched.reported,
+			State:    state,
+			Time:     now,
+		})
+		watched.reported = state
+		watched.pending = ""
+	}
+	w.mu.Unlock()
+
+	for i := range changed {
+		changed[i].Station = w.station(ctx, changed[i].Facility.StationNumber)
+	}
+	return changed
+}
+
+// station looks up the station with the given number, caching the result.
+func (w *FacilityWatcher) station(ctx context.Context, number int) *Station {
+	w.mu.Lock()
+	station, ok := w.stationCache[number]
+	w.mu.Unlock()
+	if ok {
+		return station
+	}
+
+	resp, err := w.stations.StationByIDContext(ctx, number)
+	if err != nil {
+		w.reportError(err)
+		return nil
+	}
+	if len(resp.Result) > 0 {
+		station = &resp.Result[0]
+	}
+
+	w.mu.Lock()
+	w.stationCache[number] = station
+	w.mu.Unlock()
+	return station
+}
+
+func (w *FacilityWatcher) reportError(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
@@ -0,0 +1,146 @@
+package dbapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// facilityStates serves the facilities with the given states, one list per poll.
+type facilityStates struct {
+	mu    sync.Mutex
+	polls [][]FacilityState
+}
+
+func (f *facilityStates) middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, FaStaFacilitiesEndpoint) {
+			return next.RoundTrip(req)
+		}
+
+		f.mu.Lock()
+		states := f.polls[0]
+		if len(f.polls) > 1 {
+			f.polls = f.polls[1:]
+		}
+		f.mu.Unlock()
+
+		var facilities []string
+		for i, state := range states {
+			facilities = append(facilities, fmt.Sprintf(
+				`{"equipmentnumber":%d,"type":"ELEVATOR","state":"%s","stationnumber":1}`, 100+i, state))
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("[" + strings.Join(facilities, ",") + "]")),
+			Request:    req,
+		}, nil
+	})
+}
+
+func TestFacilityWatcher_Transitions(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	states := &facilityStates{polls: [][]FacilityState{
+		{FacilityActive, FacilityActive, FacilityUnknown},
+		{FacilityInactive, FacilityActive, FacilityActive},
+		{FacilityInactive, FacilityUnknown, FacilityActive},
+		{FacilityActive, FacilityActive, FacilityInactive},
+	}}
+	c := New("SomeFakeToken", Config{Middleware: []Middleware{states.middleware}})
+	w := NewFacilityWatcher(c.FaStaAPI(), FacilityWatcherConfig{})
+
+	now := time.Now()
+	ctx := context.Background()
+
+	// The first poll only establishes the states
+	assert.Empty(w.poll(ctx, now))
+
+	events := w.poll(ctx, now.Add(time.Minute))
+	assert.Len(events, 1)
+	assert.Equal(100, events[0].Facility.EquipmentNumber)
+	assert.Equal(FacilityActive, events[0].Previous)
+	assert.Equal(FacilityInactive, events[0].State)
+	assert.Equal(now.Add(time.Minute), events[0].Time)
+	assert.Equal("Aachen Hbf", events[0].Station.Name)
+
+	// UNKNOWN keeps the last known state
+	assert.Empty(w.poll(ctx, now.Add(2*time.Minute)))
+
+	events = w.poll(ctx, now.Add(3*time.Minute))
+	assert.Len(events, 2)
+	assert.Equal(FacilityActive, events[0].State)
+	assert.Equal(102, events[1].Facility.EquipmentNumber)
+	assert.Equal(FacilityActive, events[1].Previous)
+}
+
+func TestFacilityWatcher_Debounce(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	states := &facilityStates{polls: [][]FacilityState{
+		{FacilityActive},
+		{FacilityInactive},
+		{FacilityActive},
+		{FacilityInactive},
+		{FacilityInactive},
+		{FacilityInactive},
+	}}
+	c := New("SomeFakeToken", Config{Middleware: []Middleware{states.middleware}})
+	w := NewFacilityWatcher(c.FaStaAPI(), FacilityWatcherConfig{Debounce: 2 * time.Minute})
+
+	now := time.Now()
+	ctx := context.Background()
+	var events []FacilityEvent
+	for i := 0; i < 6; i++ {
+		events = append(events, w.poll(ctx, now.Add(time.Duration(i)*time.Minute))...)
+	}
+
+	// The flap at the second poll is suppressed, the lasting change reported after two minutes
+	assert.Len(events, 1)
+	assert.Equal(FacilityInactive, events[0].State)
+	assert.Equal(now.Add(5*time.Minute), events[0].Time)
+}
+
+func TestFacilityWatcher_Run(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	states := &facilityStates{polls: [][]FacilityState{{FacilityActive}, {FacilityInactive}}}
+	var errs []error
+	c := New("SomeFakeToken", Config{Middleware: []Middleware{states.middleware}})
+	w := NewFacilityWatcher(c.FaStaAPI(), FacilityWatcherConfig{
+		Interval: 10 * time.Millisecond,
+		Stations: NewCachingStationData(c.StationDataAPI(), nil, time.Minute),
+		OnError:  func(err error) { errs = append(errs, err) },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []FacilityEvent
+	err := w.Run(ctx, func(event FacilityEvent) {
+		events = append(events, event)
+		cancel()
+	})
+
+	assert.Equal(context.Canceled, err)
+	assert.Len(events, 1)
+	assert.Equal(FacilityInactive, events[0].State)
+	assert.Empty(errs)
+}
@@ -0,0 +1,41 @@
+package dbapi
+
+import "time"
+
+// RequestInfo describes a request sent to one of the APIs.
+type RequestInfo struct {
+	// API names the API the request was sent to, e.g. "stationdata".
+	API string
+	// Endpoint is the endpoint template of the request, e.g. StationDataStationByIDEndpoint.
+	Endpoint string
+	Method   string
+	// Attempt is 1 for the first attempt of a logical request and increases with every retry.
+	Attempt int
+}
+
+// Instrumentation receives events about the requests a Client sends, e.g. to record metrics.
+// Requests served from the cache or shared with a concurrent identical request do not
+// produce events. Implementations must be safe for concurrent use and should return quickly
+// as they are called synchronously.
+type Instrumentation interface {
+	// RequestStarted is called right before a request is sent.
+	RequestStarted(info RequestInfo)
+	// RequestFinished is called once the response was read completely or the request failed.
+	// statusCode is zero if no response was received.
+	RequestFinished(info RequestInfo, statusCode int, duration time.Duration, err error)
+	// RateLimitWaited reports how long the request was held back by the rate limiter.
+	RateLimitWaited(info RequestInfo, wait time.Duration)
+}
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) RequestStarted(RequestInfo)                             {}
+func (nopInstrumentation) RequestFinished(RequestInfo, int, time.Duration, error) {}
+func (nopInstrumentation) RateLimitWaited(RequestInfo, time.Duration)             {}
+
+func (client *Client) instrumentation() Instrumentation {
+	if client.apiConfig.Instrumentation == nil {
+		return nopInstrumentation{}
+	}
+	return client.apiConfig.Instrumentation
+}
@@ -0,0 +1,59 @@
+package dbapi
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingInstrumentation struct {
+	mu       sync.Mutex
+	started  []RequestInfo
+	finished []int
+	waited   int
+}
+
+func (r *recordingInstrumentation) RequestStarted(info RequestInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = append(r.started, info)
+}
+
+func (r *recordingInstrumentation) RequestFinished(info RequestInfo, statusCode int, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = append(r.finished, statusCode)
+}
+
+func (r *recordingInstrumentation) RateLimitWaited(info RequestInfo, wait time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.waited++
+}
+
+func TestInstrumentation(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	recorder := &recordingInstrumentation{}
+	c := New("SomeFakeToken", Config{
+		StationDataConfig: StationDataConfig{RateLimitPerMinute: 6000},
+		Instrumentation:   recorder,
+	})
+	s := c.StationDataAPI()
+
+	s.StationByID(1)
+	s.SZentralenByID(99)
+
+	assert.Equal([]RequestInfo{
+		{API: "stationdata", Endpoint: StationDataStationByIDEndpoint, Method: "GET", Attempt: 1},
+		{API: "stationdata", Endpoint: StationDataSZentraleByIDEndpoint, Method: "GET", Attempt: 1},
+	}, recorder.started)
+	assert.Equal([]int{200, 404}, recorder.finished)
+	assert.Equal(2, recorder.waited)
+}
@@ -0,0 +1,209 @@
+package dbapi
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultKeySidelineDuration = time.Minute
+
+// APIKey is an additional credential for the key pool of a Client, e.g. an application
+// subscribed to another tier. Set either a static bearer Token or an Authenticator. Each key
+// has its own rate limiter per API; RateLimitPerMinute overrides the rate limit configured
+// for the APIs, zero keeps it.
+type APIKey struct {
+	Token              string
+	Authenticator      Authenticator
+	RateLimitPerMinute int
+}
+
+// KeyUsage holds the usage counters of a key in the key pool of a Client.
+type KeyUsage struct {
+	// Key identifies the key without revealing it, e.g. the last four characters of a token.
+	Key string
+	// Requests is the number of requests sent with the key.
+	Requests int64
+	// RateLimited counts the responses with status 429.
+	RateLimited int64
+	// Rejected counts the responses with status 401 or 403.
+	Rejected int64
+	// SidelinedUntil is set while the key is not used because of a 401, 403 or 429 response.
+	SidelinedUntil time.Time
+}
+
+// apiKey is a credential of the key pool together with its rate limiters and usage counters.
+type apiKey struct {
+	auth               Authenticator
+	rateLimitPerMinute int
+
+	mu       sync.Mutex
+	limiters map[string]*rateLimiter
+	usage    KeyUsage
+}
+
+// keyPool chooses the key for each request. Keys that were rejected or rate-limited by the API
+// are sidelined for a while, so requests are sent with the remaining keys.
+type keyPool struct {
+	keys     []*apiKey
+	sideline time.Duration
+}
+
+func newKeyPool(token string, apiConfig Config) *keyPool {
+	pool := &keyPool{sideline: apiConfig.KeySidelineDuration}
+	if pool.sideline <= 0 {
+		pool.sideline = defaultKeySidelineDuration
+	}
+
+	keys := []APIKey{{Token: token}, {Authenticator: apiConfig.Authenticator}}
+	for _, key := range append(keys, apiConfig.APIKeys...) {
+		auth := key.Authenticator
+		if auth == nil && key.Token != "" {
+			auth = BearerToken(key.Token)
+		}
+		if auth == nil {
+			continue
+		}
+
+		label := "custom authenticator"
+		if stringer, ok := auth.(fmt.Stringer); ok {
+			label = stringer.String()
+		}
+		pool.keys = append(pool.keys, &apiKey{
+			auth:               auth,
+			rateLimitPerMinute: key.RateLimitPerMinute,
+			limiters:           map[string]*rateLimiter{},
+			usage:              KeyUsage{Key: label},
+		})
+	}
+	return pool
+}
+
+// pick returns the key to send the next request to api with, skipping the excluded keys.
+// Keys that are not sidelined are preferred, among them the one whose rate limiter allows
+// the earliest request. If all keys are sidelined, the one that recovers first is returned.
+func (p *keyPool) pick(api string, rateLimitPerMinute int, exclude map[*apiKey]bool) *apiKey {
+	now := time.Now()
+
+	var best *apiKey
+	var bestAvailable bool
+	var bestTime time.Time
+	for _, key := range p.keys {
+		if exclude[key] {
+			continue
+		}
+
+		sidelinedUntil := key.sidelinedUntil()
+		available := !now.Before(sidelinedUntil)
+		at := sidelinedUntil
+		if available {
+			at = key.limiter(api, rateLimitPerMinute).nextSlot(now)
+		}
+
+		if best == nil || (available && !bestAvailable) || (available == bestAvailable && at.Before(bestTime)) {
+			best, bestAvailable, bestTime = key, available, at
+		}
+	}
+	return best
+}
+
+// record updates the usage counters of the key and sidelines it if the API refused it.
+// It reports whether the key was sidelined.
+func (p *keyPool) record(key *apiKey, resp *response) bool {
+	key.mu.Lock()
+	defer key.mu.Unlock()
+
+	key.usage.Requests++
+	if resp == nil {
+		return false
+	}
+
+	switch resp.statusCode {
+	case http.StatusTooManyRequests:
+		key.usage.RateLimited++
+	case http.StatusUnauthorized, http.StatusForbidden:
+		key.usage.Rejected++
+		if invalidator, ok := key.auth.(interface{ Invalidate() }); ok && resp.statusCode == http.StatusUnauthorized {
+			invalidator.Invalidate()
+		}
+	default:
+		return false
+	}
+	key.usage.SidelinedUntil = time.Now().Add(p.sideline)
+	return true
+}
+
+// renew drops the credentials of the key after a 401 response if its Authenticator supports
+// it, e.g. an expired or revoked OAuth2 access token. It reports whether the request should be
+// retried with the same key.
+func (p *keyPool) renew(key *apiKey, resp *response) bool {
+	if resp == nil || resp.statusCode != http.StatusUnauthorized {
+		return false
+	}
+	invalidator, ok := key.auth.(interface{ Invalidate() })
+	if !ok {
+		return false
+	}
+	invalidator.Invalidate()
+
+	key.mu.Lock()
+	defer key.mu.Unlock()
+
+	key.usage.Requests++
+	key.usage.Rejected++
+	return true
+}
+
+// available reports whether a key that is not excluded and not sidelined is left.
+func (p *keyPool) available(exclude map[*apiKey]bool) bool {
+	now := time.Now()
+	for _, key := range p.keys {
+		if !exclude[key] && !now.Before(key.sidelinedUntil()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *apiKey) sidelinedUntil() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.usage.SidelinedUntil
+}
+
+// limiter returns the rate limiter of the key for api, creating it on first use.
+func (k *apiKey) limiter(api string, rateLimitPerMinute int) *rateLimiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.rateLimitPerMinute > 0 {
+		rateLimitPerMinute = k.rateLimitPerMinute
+	}
+	limiter, ok := k.limiters[api]
+	if !ok {
+		limiter = newRateLimiter(rateLimitPerMinute)
+		k.limiters[api] = limiter
+	}
+	return limiter
+}
+
+// KeyUsage returns the usage counters of all keys in the key pool, starting with the
+// token given to New and Config.Authenticator.
+func (client *Client) KeyUsage() []KeyUsage {
+	usage := make([]KeyUsage, 0, len(client.keys.keys))
+	for _, key := range client.keys.keys {
+		key.mu.Lock()
+		usage = append(usage, key.usage)
+		key.mu.Unlock()
+	}
+	return usage
+}
+
+func redactToken(token string) string {
+	if len(token) <= 4 {
+		return redacted
+	}
+	return "..." + token[len(token)-4:]
+}
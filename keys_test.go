@@ -0,0 +1,114 @@
+package dbapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPool_Rotation(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var tokens []string
+	var attempts []int
+	c := New("FirstToken", Config{
+		APIKeys: []APIKey{{Token: "SecondToken"}},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				tokens = append(tokens, req.Header.Get("Authorization"))
+				op, _ := OperationFromContext(req.Context())
+				attempts = append(attempts, op.Attempt)
+				if req.Header.Get("Authorization") == "Bearer FirstToken" {
+					return &http.Response{
+						StatusCode: http.StatusTooManyRequests,
+						Body:       io.NopCloser(strings.NewReader(`{"error":{"code":900802,"message":"Message throttled out"}}`)),
+						Request:    req,
+					}, nil
+				}
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+	s := c.StationDataAPI()
+
+	stationResp, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+
+	// The first key is sidelined now
+	_, err = s.StationByID(1)
+	assert.Nil(err)
+
+	assert.Equal([]string{"Bearer FirstToken", "Bearer SecondToken", "Bearer SecondToken"}, tokens)
+	assert.Equal([]int{1, 2, 1}, attempts)
+
+	usage := c.KeyUsage()
+	assert.Len(usage, 2)
+	assert.Equal("...oken", usage[0].Key)
+	assert.Equal(int64(1), usage[0].Requests)
+	assert.Equal(int64(1), usage[0].RateLimited)
+	assert.True(usage[0].SidelinedUntil.After(time.Now()))
+	assert.Equal(int64(2), usage[1].Requests)
+	assert.True(usage[1].SidelinedUntil.IsZero())
+}
+
+func TestKeyPool_SingleKeyNotRetried(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	calls := 0
+	c := New("OnlyToken", Config{
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return &http.Response{
+					StatusCode: http.StatusUnauthorized,
+					Body:       io.NopCloser(strings.NewReader("Invalid Credentials")),
+					Request:    req,
+				}, nil
+			})
+		}},
+	})
+	s := c.StationDataAPI()
+
+	_, err := s.StationByID(1)
+	assert.EqualError(err, "Invalid Credentials")
+	_, err = s.StationByID(1)
+	assert.EqualError(err, "Invalid Credentials")
+	assert.Equal(2, calls)
+	assert.Equal(int64(2), c.KeyUsage()[0].Rejected)
+}
+
+func TestKeyPool_RateLimitPerKey(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("FirstToken", Config{
+		StationDataConfig: StationDataConfig{RateLimitPerMinute: 1},
+		APIKeys:           []APIKey{{Token: "SecondToken"}},
+	})
+	s := c.StationDataAPI()
+
+	start := time.Now()
+	s.StationByID(1)
+	s.StationByID(1)
+	assert.True(time.Since(start) < time.Second, "each key has its own budget")
+
+	usage := c.KeyUsage()
+	assert.Equal(int64(1), usage[0].Requests)
+	assert.Equal(int64(1), usage[1].Requests)
+}
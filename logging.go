@@ -0,0 +1,128 @@
+package dbapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	redacted        = "[REDACTED]"
+	maxLoggedBody   = 4096
+	truncatedSuffix = "...(truncated)"
+)
+
+// LogConfig enables structured logging of the requests sent to the APIs. Logging is disabled
+// if no Logger is set.
+//
+// Successful requests are logged at SuccessLevel (default slog.LevelDebug), failed requests
+// and responses with a status code >= 400 at FailureLevel (default slog.LevelWarn). Each
+// record contains the method, path, query, status, duration and response size. Set
+// DumpFailedBodies to also log the request headers and the response body of failed requests.
+// Credentials such as the Authorization header are always redacted.
+type LogConfig struct {
+	Logger           *slog.Logger
+	SuccessLevel     slog.Leveler
+	FailureLevel     slog.Leveler
+	DumpFailedBodies bool
+}
+
+// sensitiveHeaders are never logged in clear text.
+var sensitiveHeaders = []string{"Authorization", "DB-Api-Key"}
+
+// LogValue implements slog.LogValuer, so logging a Client never reveals its API token.
+func (client *Client) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("apiToken", redacted))
+}
+
+func (client *Client) logRequest(ctx context.Context, req *http.Request, info RequestInfo, resp *response, duration time.Duration, err error) {
+	cfg := client.apiConfig.LogConfig
+	if cfg.Logger == nil {
+		return
+	}
+
+	failed := err != nil || (resp != nil && resp.statusCode >= 400)
+	level := leveler(cfg.SuccessLevel, slog.LevelDebug)
+	if failed {
+		level = leveler(cfg.FailureLevel, slog.LevelWarn)
+	}
+	if !cfg.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("api", info.API),
+		slog.String("endpoint", info.Endpoint),
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("query", req.URL.RawQuery),
+		slog.Int("attempt", info.Attempt),
+		slog.Duration("duration", duration),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.statusCode),
+			slog.Int("size", len(resp.body)))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", client.redact(err.Error())))
+	}
+	if failed && cfg.DumpFailedBodies {
+		attrs = append(attrs, slog.Any("headers", redactHeader(req.Header)))
+		if resp != nil {
+			attrs = append(attrs, slog.String("body", client.redact(truncate(string(resp.body), maxLoggedBody))))
+		}
+	}
+
+	cfg.Logger.LogAttrs(ctx, level, "dbapi request", attrs...)
+}
+
+// redact removes the credentials of the key pool from s in case the API echoes them.
+func (client *Client) redact(s string) string {
+	for _, key := range client.keys.keys {
+		holder, ok := key.auth.(secretHolder)
+		if !ok {
+			continue
+		}
+		for _, secret := range holder.secrets() {
+			if secret != "" {
+				s = strings.ReplaceAll(s, secret, redacted)
+			}
+		}
+	}
+	return s
+}
+
+func redactHeader(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, key := range sensitiveHeaders {
+		if values := clone.Values(key); len(values) > 0 {
+			clone.Set(key, redactCredential(values[0]))
+		}
+	}
+	return clone
+}
+
+// redactCredential keeps the authentication scheme, e.g. "Bearer", but removes the credential.
+func redactCredential(value string) string {
+	if i := strings.Index(value, " "); i > 0 {
+		return value[:i+1] + redacted
+	}
+	return redacted
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + truncatedSuffix
+}
+
+func leveler(l slog.Leveler, fallback slog.Level) slog.Level {
+	if l == nil {
+		return fallback
+	}
+	return l.Level()
+}
@@ -0,0 +1,54 @@
+package dbapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogging(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := New("SecretToken123", Config{
+		LogConfig: LogConfig{
+			Logger:           logger,
+			DumpFailedBodies: true,
+		},
+	})
+	s := c.StationDataAPI()
+
+	s.StationByID(1)
+	s.StationByFilter(StationDataStationRequest{Federalstate: "bayern"})
+	logger.Info("client", "client", c)
+
+	assert.NotContains(buf.String(), "SecretToken123")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 3)
+
+	var success, failure map[string]interface{}
+	assert.Nil(json.Unmarshal([]byte(lines[0]), &success))
+	assert.Nil(json.Unmarshal([]byte(lines[1]), &failure))
+
+	assert.Equal("DEBUG", success["level"])
+	assert.Equal("/stada/v2/stations/1", success["path"])
+	assert.Equal(200.0, success["status"])
+	assert.NotContains(success, "headers")
+
+	assert.Equal("WARN", failure["level"])
+	assert.Equal("federalstate=bayern", failure["query"])
+	assert.Equal(404.0, failure["status"])
+	assert.Equal(`{"errNo":404,"errMsg":"Not Found"}`, failure["body"])
+	assert.Equal([]interface{}{"Bearer [REDACTED]"}, failure["headers"].(map[string]interface{})["Authorization"])
+}
@@ -0,0 +1,56 @@
+package dbapi
+
+import (
+	"context"
+	"net/http"
+)
+
+// Middleware wraps the round trip of every request a Client sends to any of the APIs, e.g. to
+// add headers, sign or audit requests or to inject faults in tests. Middlewares see requests
+// after rate limiting and authentication have been applied. Use OperationFromContext on the
+// request context to find out which API call a request belongs to.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to use an ordinary function as http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Operation describes the logical API call a request is sent for.
+type Operation struct {
+	// API names the API, e.g. "stationdata".
+	API string
+	// Name is the name of the method that was called, e.g. "StationByID".
+	Name string
+	// Endpoint is the endpoint template, e.g. StationDataStationByIDEndpoint.
+	Endpoint string
+	// Attempt is 1 for the first request of the call and increases with every retry.
+	Attempt int
+}
+
+type operationKey struct{}
+
+// OperationFromContext returns the Operation stored in the context of a request sent by a Client.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+func withOperation(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// chainMiddleware wraps transport with the middlewares. The first middleware is the outermost
+// one: it sees the request first and the response last.
+func chainMiddleware(transport http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	return transport
+}
@@ -0,0 +1,81 @@
+package dbapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var order []string
+	var operations []Operation
+	recordMiddleware := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				resp, err := next.RoundTrip(req)
+				order = append(order, name+" response")
+				return resp, err
+			})
+		}
+	}
+	operationMiddleware := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			op, ok := OperationFromContext(req.Context())
+			assert.True(ok)
+			operations = append(operations, op)
+			assert.Equal("Bearer SomeFakeToken", req.Header.Get("Authorization"))
+			return next.RoundTrip(req)
+		})
+	}
+
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{recordMiddleware("outer"), recordMiddleware("inner"), operationMiddleware},
+	})
+	s := c.StationDataAPI()
+
+	stationResp, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+
+	assert.Equal([]string{"outer request", "inner request", "inner response", "outer response"}, order)
+	assert.Equal([]Operation{{
+		API:      "stationdata",
+		Name:     "StationByID",
+		Endpoint: StationDataStationByIDEndpoint,
+		Attempt:  1,
+	}}, operations)
+}
+
+func TestMiddleware_FaultInjection(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(strings.NewReader(`{"errNo":500,"errMsg":"Injected"}`)),
+					Request:    req,
+				}, nil
+			})
+		}},
+	})
+	s := c.StationDataAPI()
+
+	_, err := s.StationByID(1)
+	assert.Equal(&StationDataErrorResponse{ErrNo: 500, ErrMsg: "Injected"}, err)
+}
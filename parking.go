@@ -0,0 +1,269 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/go-querystring/query"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const parkingAPIPath = "/bahnpark/v1"
+
+// parkingAPIName identifies the Parking API in instrumentation events.
+const parkingAPIName = "parking"
+
+// parkingPageSize is the number of spaces fetched per request by StationSpaces.
+const parkingPageSize = 1000
+
+// Endpoint templates of the Parking API.
+const (
+	ParkingSpacesEndpoint         = parkingAPIPath + "/spaces"
+	ParkingSpaceEndpoint          = parkingAPIPath + "/spaces/{id}"
+	ParkingOccupanciesEndpoint    = parkingAPIPath + "/spaces/occupancies"
+	ParkingSpaceOccupancyEndpoint = parkingAPIPath + "/spaces/{id}/occupancies"
+)
+
+// ParkingConfig provides configuration options for the Parking API. Set RateLimitPerMinute to
+// zero if you want to disable rate limiting done in the library.
+type ParkingConfig struct {
+	RateLimitPerMinute int
+}
+
+// ParkingSpace is a car park or parking lot managed by DB BahnPark. Station.ID refers to
+// Station.Number of the StationData API.
+type ParkingSpace struct {
+	ID          int            `json:"id"`
+	Title       string         `json:"title"`
+	Station     ParkingStation `json:"station"`
+	SpaceType   string         `json:"spaceType"`
+	SpaceTypeEn string         `json:"spaceTypeEn"`
+	// Capacity is the number of parking places, HandicappedCapacity the number of places
+	// reserved for disabled people.
+	Capacity            int             `json:"numberParkingPlaces,string"`
+	HandicappedCapacity int             `json:"numberHandicapedPlaces,string"`
+	Address             ParkingAddress  `json:"address"`
+	Location            ParkingLocation `json:"geoLocation"`
+	// OpeningHours describes the opening hours, e.g. "24 Stunden, 7 Tage".
+	OpeningHours   string         `json:"openingHours"`
+	OpeningHoursEn string         `json:"openingHoursEn"`
+	Operator       string         `json:"operator"`
+	OperatorURL    string         `json:"operatorUrl"`
+	Prices         []ParkingPrice `json:"tariffPrices"`
+	// HasOccupancy is set if the space reports occupancy data.
+	HasOccupancy bool `json:"isMonitored"`
+}
+
+// ParkingStation is the station a ParkingSpace belongs to.
+type ParkingStation struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ParkingAddress holds the postal address of a ParkingSpace.
+type ParkingAddress struct {
+	Street     string `json:"street"`
+	PostalCode string `json:"postalCode"`
+	City       string `json:"cityName"`
+}
+
+// ParkingLocation holds the coordinates of the entrance of a ParkingSpace.
+type ParkingLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ParkingPrice is the price in euro for parking the given duration, e.g. "1day".
+type ParkingPrice struct {
+	Duration string  `json:"duration"`
+	Price    float64 `json:"price"`
+}
+
+// ParkingSpacesRequest is used by Spaces to page through all spaces.
+type ParkingSpacesRequest struct {
+	Offset int `url:"offset,omitempty"`
+	Limit  int `url:"limit,omitempty"`
+}
+
+// ParkingSpacesResponse holds meta information about the response and the spaces.
+type ParkingSpacesResponse struct {
+	Staleness
+
+	TotalCount int            `json:"totalCount"`
+	Count      int            `json:"count"`
+	Offset     int            `json:"offset"`
+	Limit      int            `json:"limit"`
+	Items      []ParkingSpace `json:"items"`
+}
+
+// ParkingOccupancy is the current occupancy of a ParkingSpace.
+type ParkingOccupancy struct {
+	Space      ParkingSpaceRef   `json:"space"`
+	Allocation ParkingAllocation `json:"allocation"`
+}
+
+// ParkingSpaceRef identifies the space of a ParkingOccupancy.
+type ParkingSpaceRef struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	StationID int    `json:"stationId"`
+}
+
+// ParkingAllocation describes how many places of a space are free, in categories rather than
+// exact numbers: 1 means up to 10, 2 more than 10, 3 more than 30 and 4 more than 50 free places.
+type ParkingAllocation struct {
+	// ValidData is false if the occupancy is currently not known.
+	ValidData bool `json:"validData"`
+	// Timestamp is the time the occupancy was measured, TimeSegment the start of the
+	// measured interval.
+	Timestamp   time.Time `json:"timestamp"`
+	TimeSegment time.Time `json:"timeSegment"`
+	Category    int       `json:"category"`
+	// Text describes the category, e.g. "> 10".
+	Text     string `json:"text"`
+	Capacity int    `json:"capacity"`
+}
+
+// ParkingOccupanciesResponse holds the occupancies of all spaces reporting them.
+type ParkingOccupanciesResponse struct {
+	Allocations []ParkingOccupancy `json:"allocations"`
+}
+
+// ParkingAPI is a struct holding internal information about this API. Its methods can be used
+// to query the API.
+type ParkingAPI struct {
+	client *Client
+}
+
+// Spaces returns a page of all parking spaces.
+func (p *ParkingAPI) Spaces(spacesRequest ParkingSpacesRequest) (*ParkingSpacesResponse, error) {
+	return p.SpacesContext(context.Background(), spacesRequest)
+}
+
+// SpacesContext is like Spaces but aborts waiting for the rate limiter and the request once
+// ctx is done.
+func (p *ParkingAPI) SpacesContext(ctx context.Context, spacesRequest ParkingSpacesRequest) (*ParkingSpacesResponse, error) {
+	q, err := query.Values(spacesRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s%s/spaces", p.client.baseURL(), parkingAPIPath)
+	if len(q) > 0 {
+		url += "?" + q.Encode()
+	}
+
+	psr := &ParkingSpacesResponse{}
+	err = p.get(ctx, &call{
+		operation:  "Spaces",
+		endpoint:   ParkingSpacesEndpoint,
+		url:        url,
+		attributes: filterAttributes(q),
+	}, psr)
+	return psr, err
+}
+
+// SpaceByID returns the parking space with the given id.
+func (p *ParkingAPI) SpaceByID(id int) (*ParkingSpace, error) {
+	return p.SpaceByIDContext(context.Background(), id)
+}
+
+// SpaceByIDContext is like SpaceByID but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (p *ParkingAPI) SpaceByIDContext(ctx context.Context, id int) (*ParkingSpace, error) {
+	url := fmt.Sprintf("%s%s/spaces/%d", p.client.baseURL(), parkingAPIPath, id)
+
+	space := &ParkingSpace{}
+	err := p.get(ctx, &call{
+		operation:  "SpaceByID",
+		endpoint:   ParkingSpaceEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.parking.space.id", id)},
+	}, space)
+	return space, err
+}
+
+// StationSpaces returns the parking spaces of the station with the given number, which is the
+// Station.Number of the StationData API. As the API cannot filter by station, all spaces are
+// fetched; enable caching to avoid repeating that for every station.
+func (p *ParkingAPI) StationSpaces(stationNumber int) ([]ParkingSpace, error) {
+	return p.StationSpacesContext(context.Background(), stationNumber)
+}
+
+// StationSpacesContext is like StationSpaces but aborts waiting for the rate limiter and the
+// requests once ctx is done.
+func (p *ParkingAPI) StationSpacesContext(ctx context.Context, stationNumber int) ([]ParkingSpace, error) {
+	var spaces []ParkingSpace
+	for offset := 0; ; {
+		psr, err := p.SpacesContext(ctx, ParkingSpacesRequest{Offset: offset, Limit: parkingPageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, space := range psr.Items {
+			if space.Station.ID == stationNumber {
+				spaces = append(spaces, space)
+			}
+		}
+
+		offset += len(psr.Items)
+		if len(psr.Items) == 0 || offset >= psr.TotalCount {
+			return spaces, nil
+		}
+	}
+}
+
+// Occupancies returns the current occupancy of all spaces reporting it.
+func (p *ParkingAPI) Occupancies() (*ParkingOccupanciesResponse, error) {
+	return p.OccupanciesContext(context.Background())
+}
+
+// OccupanciesContext is like Occupancies but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (p *ParkingAPI) OccupanciesContext(ctx context.Context) (*ParkingOccupanciesResponse, error) {
+	url := fmt.Sprintf("%s%s/spaces/occupancies", p.client.baseURL(), parkingAPIPath)
+
+	por := &ParkingOccupanciesResponse{}
+	err := p.get(ctx, &call{
+		operation: "Occupancies",
+		noCache:   true,
+		endpoint:  ParkingOccupanciesEndpoint,
+		url:       url,
+	}, por)
+	return por, err
+}
+
+// SpaceOccupancy returns the current occupancy of the space with the given id.
+func (p *ParkingAPI) SpaceOccupancy(id int) (*ParkingOccupancy, error) {
+	return p.SpaceOccupancyContext(context.Background(), id)
+}
+
+// SpaceOccupancyContext is like SpaceOccupancy but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (p *ParkingAPI) SpaceOccupancyContext(ctx context.Context, id int) (*ParkingOccupancy, error) {
+	url := fmt.Sprintf("%s%s/spaces/%d/occupancies", p.client.baseURL(), parkingAPIPath, id)
+
+	occupancy := &ParkingOccupancy{}
+	err := p.get(ctx, &call{
+		operation:  "SpaceOccupancy",
+		noCache:    true,
+		endpoint:   ParkingSpaceOccupancyEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.parking.space.id", id)},
+	}, occupancy)
+	return occupancy, err
+}
+
+func (p *ParkingAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = parkingAPIName
+	c.rateLimitPerMinute = p.client.apiConfig.ParkingConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := p.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(parkingAPIName, resp, data, json.Unmarshal)
+}
@@ -0,0 +1,149 @@
+package dbapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/bahnpark/v1/", ".json", `{"code":404,"message":"Not Found"}`)
+}
+
+func TestParkingAPI_Spaces(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var query string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{captureQuery(&query)},
+	})
+	p := c.ParkingAPI()
+
+	spaces, err := p.Spaces(ParkingSpacesRequest{})
+	assert.Nil(err)
+	assert.Equal("", query)
+	assert.Equal(3, spaces.TotalCount)
+	assert.Len(spaces.Items, 3)
+	assert.Equal(ParkingSpace{
+		ID:                  100082,
+		Title:               "Frankfurt (Main) Hbf P1 Parkhaus",
+		Station:             ParkingStation{ID: 1866, Name: "Frankfurt (Main) Hbf"},
+		SpaceType:           "Parkhaus",
+		SpaceTypeEn:         "Multi storey car park",
+		Capacity:            850,
+		HandicappedCapacity: 12,
+		Address:             ParkingAddress{Street: "Poststraße 5", PostalCode: "60329", City: "Frankfurt am Main"},
+		Location:            ParkingLocation{Latitude: 50.108386, Longitude: 8.666178},
+		OpeningHours:        "24 Stunden, 7 Tage",
+		OpeningHoursEn:      "24 hours, 7 days",
+		Operator:            "Contipark",
+		OperatorURL:         "https://www.contipark.de",
+		Prices:              []ParkingPrice{{Duration: "20min", Price: 1.5}, {Duration: "1day", Price: 29}},
+		HasOccupancy:        true,
+	}, spaces.Items[0])
+
+	_, err = p.Spaces(ParkingSpacesRequest{Offset: 10, Limit: 5})
+	assert.Nil(err)
+	assert.Equal("limit=5&offset=10", query)
+}
+
+func TestParkingAPI_SpaceByID(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	p := c.ParkingAPI()
+
+	space, err := p.SpaceByID(100082)
+	assert.Nil(err)
+	assert.Equal(850, space.Capacity)
+	assert.Equal(1866, space.Station.ID)
+
+	_, err = p.SpaceByID(1)
+	assert.Equal(&APIError{API: "parking", StatusCode: 404, Message: `{"code":404,"message":"Not Found"}`}, err)
+}
+
+func TestParkingAPI_StationSpaces(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+
+	spaces, err := c.ParkingAPI().StationSpaces(1866)
+	assert.Nil(err)
+	assert.Len(spaces, 2)
+	assert.Equal(100082, spaces[0].ID)
+	assert.Equal(100083, spaces[1].ID)
+
+	spaces, err = c.ParkingAPI().StationSpaces(1)
+	assert.Nil(err)
+	assert.Empty(spaces)
+}
+
+func TestParkingAPI_Occupancies(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	p := c.ParkingAPI()
+
+	occupancies, err := p.Occupancies()
+	assert.Nil(err)
+	assert.Len(occupancies.Allocations, 2)
+	assert.False(occupancies.Allocations[1].Allocation.ValidData)
+
+	occupancy, err := p.SpaceOccupancy(100082)
+	assert.Nil(err)
+	assert.Equal(ParkingSpaceRef{ID: 100082, Title: "Frankfurt (Main) Hbf P1 Parkhaus", StationID: 1866}, occupancy.Space)
+	assert.True(occupancy.Allocation.ValidData)
+	assert.Equal(3, occupancy.Allocation.Category)
+	assert.Equal("> 30", occupancy.Allocation.Text)
+	assert.Equal(850, occupancy.Allocation.Capacity)
+	assert.True(time.Date(2022, 7, 14, 12, 4, 31, 0, berlin).Equal(occupancy.Allocation.Timestamp))
+}
+
+func TestParkingAPI_OccupanciesNotCached(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var requests int
+	c := New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{Backend: NewMemoryCache(10), DefaultTTL: time.Hour},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+	p := c.ParkingAPI()
+
+	for i := 0; i < 2; i++ {
+		_, err := p.Occupancies()
+		assert.Nil(err)
+		_, err = p.SpaceOccupancy(100082)
+		assert.Nil(err)
+		_, err = p.SpaceByID(100082)
+		assert.Nil(err)
+	}
+	// Only the space is served from the cache
+	assert.Equal(5, requests)
+}
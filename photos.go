@@ -0,0 +1,229 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+
+	"github.com/google/go-querystring/query"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const photosAPIPath = "/bahnhofsfotos/v1"
+
+// photosAPIName identifies the Photos API in instrumentation events.
+const photosAPIName = "photos"
+
+// photosGermany is the country code of German stations, whose ids are eva numbers.
+const photosGermany = "de"
+
+// Endpoint templates of the Photos API.
+const (
+	PhotosStationsEndpoint = photosAPIPath + "/{country}/stations"
+	PhotosStationEndpoint  = photosAPIPath + "/{country}/stations/{id}"
+)
+
+// ErrNoEvaNumber is returned by PhotosAPI.ByStation for stations without eva number.
+var ErrNoEvaNumber = errors.New("dbapi: station has no eva number")
+
+// PhotosConfig provides configuration options for the Photos API. Set RateLimitPerMinute to
+// zero if you want to disable rate limiting done in the library.
+type PhotosConfig struct {
+	RateLimitPerMinute int
+}
+
+// PhotoStation is a station of the railway-stations.org project along with its photo. The
+// photo fields are empty if nobody has taken a photo of the station yet. For German stations
+// ID is the eva number of the StationData API.
+type PhotoStation struct {
+	ID        int     `json:"id"`
+	Title     string  `json:"title"`
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+	DS100     string  `json:"DS100,omitempty"`
+	Active    bool    `json:"active"`
+
+	PhotoURL        string `json:"photoUrl,omitempty"`
+	Photographer    string `json:"photographer,omitempty"`
+	PhotographerURL string `json:"photographerUrl,omitempty"`
+	// License is the name of the license of the photo, e.g. "CC0 1.0 Universell (CC0 1.0)".
+	License    string `json:"license,omitempty"`
+	LicenseURL string `json:"licenseUrl,omitempty"`
+}
+
+// HasPhoto reports whether there is a photo of the station.
+func (p *PhotoStation) HasPhoto() bool {
+	return p.PhotoURL != ""
+}
+
+// PhotosStationsRequest is used by Stations to filter the stations of a country. If it's not
+// changed, all stations are queried.
+type PhotosStationsRequest struct {
+	// HasPhoto restricts the result to stations with or without photo if set.
+	HasPhoto     *bool  `url:"hasPhoto,omitempty"`
+	Photographer string `url:"photographer,omitempty"`
+}
+
+// PhotosAPI is a struct holding internal information about this API. Its methods can be used
+// to query the API.
+type PhotosAPI struct {
+	client *Client
+}
+
+// Stations returns the stations of the country with the given code, e.g. "de", matching the
+// filter.
+func (p *PhotosAPI) Stations(country string, stationsRequest PhotosStationsRequest) ([]PhotoStation, error) {
+	return p.StationsContext(context.Background(), country, stationsRequest)
+}
+
+// StationsContext is like Stations but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (p *PhotosAPI) StationsContext(ctx context.Context, country string, stationsRequest PhotosStationsRequest) ([]PhotoStation, error) {
+	q, err := query.Values(stationsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s%s/%s/stations", p.client.baseURL(), photosAPIPath, url.PathEscape(country))
+	if len(q) > 0 {
+		url += "?" + q.Encode()
+	}
+
+	var stations []PhotoStation
+	err = p.get(ctx, &call{
+		operation:  "Stations",
+		endpoint:   PhotosStationsEndpoint,
+		url:        url,
+		attributes: append(filterAttributes(q), attribute.String("dbapi.photos.country", country)),
+	}, &stations)
+	return stations, err
+}
+
+// StationByID returns the station of the country with the given code and id.
+func (p *PhotosAPI) StationByID(country string, id int) (*PhotoStation, error) {
+	return p.StationByIDContext(context.Background(), country, id)
+}
+
+// StationByIDContext is like StationByID but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (p *PhotosAPI) StationByIDContext(ctx context.Context, country string, id int) (*PhotoStation, error) {
+	url := fmt.Sprintf("%s%s/%s/stations/%d", p.client.baseURL(), photosAPIPath, url.PathEscape(country), id)
+
+	station := &PhotoStation{}
+	err := p.get(ctx, &call{
+		operation: "StationByID",
+		endpoint:  PhotosStationEndpoint,
+		url:       url,
+		attributes: []attribute.KeyValue{
+			attribute.String("dbapi.photos.country", country),
+			attribute.Int("dbapi.station.id", id),
+		},
+	}, station)
+	return station, err
+}
+
+// ByStation returns the photo station of a StationData station, looked up by its main eva
+// number.
+func (p *PhotosAPI) ByStation(station *Station) (*PhotoStation, error) {
+	return p.ByStationContext(context.Background(), station)
+}
+
+// ByStationContext is like ByStation but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (p *PhotosAPI) ByStationContext(ctx context.Context, station *Station) (*PhotoStation, error) {
+	eva, ok := mainEvaNumber(station)
+	if !ok {
+		return nil, ErrNoEvaNumber
+	}
+	return p.StationByIDContext(ctx, photosGermany, eva)
+}
+
+func (p *PhotosAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = photosAPIName
+	c.rateLimitPerMinute = p.client.apiConfig.PhotosConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := p.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(photosAPIName, resp, data, json.Unmarshal)
+}
+
+// PhotoIndex looks up photo stations offline. The project publishes the stations of every
+// country as JSON file in the format returned by Stations, which LoadPhotoIndex reads. It is
+// safe for concurrent use as it is never modified after creation.
+type PhotoIndex struct {
+	all  []PhotoStation
+	byID map[int]int
+}
+
+// NewPhotoIndex creates an index of the given photo stations.
+func NewPhotoIndex(stations []PhotoStation) *PhotoIndex {
+	all := append([]PhotoStation(nil), stations...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+
+	index := &PhotoIndex{all: all, byID: make(map[int]int, len(all))}
+	for i, station := range all {
+		index.byID[station.ID] = i
+	}
+	return index
+}
+
+// LoadPhotoIndex reads a JSON photo index file of one country.
+func LoadPhotoIndex(path string) (*PhotoIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stations []PhotoStation
+	if err := json.Unmarshal(data, &stations); err != nil {
+		return nil, fmt.Errorf("dbapi: invalid photo index file %s: %w", path, err)
+	}
+	return NewPhotoIndex(stations), nil
+}
+
+// All returns all photo stations sorted by id.
+func (i *PhotoIndex) All() []PhotoStation {
+	return append([]PhotoStation(nil), i.all...)
+}
+
+// ByID returns the photo station with the given id.
+func (i *PhotoIndex) ByID(id int) (PhotoStation, bool) {
+	index, ok := i.byID[id]
+	if !ok {
+		return PhotoStation{}, false
+	}
+	return i.all[index], true
+}
+
+// ByStation returns the photo station of a StationData station, looked up by its main eva
+// number. The index has to be the one of Germany.
+func (i *PhotoIndex) ByStation(station *Station) (PhotoStation, bool) {
+	eva, ok := mainEvaNumber(station)
+	if !ok {
+		return PhotoStation{}, false
+	}
+	return i.ByID(eva)
+}
+
+// mainEvaNumber returns the main eva number of the station, or the first one if none is
+// marked as main. A nil station has no eva number.
+func mainEvaNumber(station *Station) (int, bool) {
+	if station == nil || len(station.EvaNumbers) == 0 {
+		return 0, false
+	}
+	for _, eva := range station.EvaNumbers {
+		if eva.IsMain {
+			return eva.Number, true
+		}
+	}
+	return station.EvaNumbers[0].Number, true
+}
@@ -0,0 +1,119 @@
+package dbapi
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/bahnhofsfotos/v1/", ".json", `{"code":404,"message":"Not Found"}`)
+}
+
+func TestPhotosAPI_Stations(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var query string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{captureQuery(&query)},
+	})
+	p := c.PhotosAPI()
+
+	stations, err := p.Stations("de", PhotosStationsRequest{})
+	assert.Nil(err)
+	assert.Equal("", query)
+	assert.Len(stations, 3)
+	assert.Equal(PhotoStation{
+		ID:              8000105,
+		Title:           "Frankfurt (Main) Hbf",
+		Latitude:        50.107145,
+		Longitude:       8.663789,
+		DS100:           "FF",
+		Active:          true,
+		PhotoURL:        "https://railway-stations.org/bahnhofsfotos/de/8000105.jpg",
+		Photographer:    "@bahnfan",
+		PhotographerURL: "https://railway-stations.org/photographer/@bahnfan",
+		License:         "CC BY-SA 4.0",
+		LicenseURL:      "https://creativecommons.org/licenses/by-sa/4.0/",
+	}, stations[1])
+	assert.False(stations[2].HasPhoto())
+
+	hasPhoto := true
+	_, err = p.Stations("de", PhotosStationsRequest{HasPhoto: &hasPhoto, Photographer: "@bahnfan"})
+	assert.Nil(err)
+	assert.Equal("hasPhoto=true&photographer=%40bahnfan", query)
+}
+
+func TestPhotosAPI_StationByID(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	p := c.PhotosAPI()
+
+	station, err := p.StationByID("de", 8000105)
+	assert.Nil(err)
+	assert.True(station.HasPhoto())
+	assert.Equal("@bahnfan", station.Photographer)
+
+	_, err = p.StationByID("ch", 8503000)
+	assert.Equal(&APIError{API: "photos", StatusCode: 404, Message: `{"code":404,"message":"Not Found"}`}, err)
+}
+
+func TestPhotosAPI_ByStation(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+
+	stationResp, err := c.StationDataAPI().StationByID(1)
+	assert.Nil(err)
+
+	photo, err := c.PhotosAPI().ByStation(&stationResp.Result[0])
+	assert.Nil(err)
+	assert.Equal(8000001, photo.ID)
+	assert.Equal("CC0 1.0 Universell (CC0 1.0)", photo.License)
+
+	_, err = c.PhotosAPI().ByStation(&Station{})
+	assert.Equal(ErrNoEvaNumber, err)
+	_, err = c.PhotosAPI().ByStation(nil)
+	assert.Equal(ErrNoEvaNumber, err)
+}
+
+func TestPhotoIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	index, err := LoadPhotoIndex(filepath.Join("testdata", "bahnhofsfotos", "v1", "de", "stations.json"))
+	assert.Nil(err)
+	assert.Len(index.All(), 3)
+
+	station, ok := index.ByID(8000105)
+	assert.True(ok)
+	assert.Equal("Frankfurt (Main) Hbf", station.Title)
+
+	_, ok = index.ByID(1)
+	assert.False(ok)
+
+	station, ok = index.ByStation(&Station{EvaNumbers: []EvaNumbers{{Number: 8000446}, {Number: 8000001, IsMain: true}}})
+	assert.True(ok)
+	assert.Equal("Aachen Hbf", station.Title)
+
+	_, ok = index.ByStation(&Station{})
+	assert.False(ok)
+	_, ok = index.ByStation(nil)
+	assert.False(ok)
+
+	_, err = LoadPhotoIndex(filepath.Join("testdata", "bahnhofsfotos", "v1", "de", "missing.json"))
+	assert.Error(err)
+}
@@ -0,0 +1,77 @@
+package dbapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces requests evenly so that no more than the configured number of
+// requests per minute are sent. It is safe for concurrent use; concurrent callers are
+// assigned consecutive slots. A nil rateLimiter does not limit at all.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		interval: time.Duration(60.0/float64(requestsPerMinute)*1000) * time.Millisecond,
+	}
+}
+
+// wait blocks until the next request may be sent or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Hand the slot back if nobody queued up behind us in the meantime
+		l.mu.Lock()
+		if l.next.Equal(slot.Add(l.interval)) {
+			l.next = slot
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// nextSlot returns the earliest time a request may be sent.
+func (l *rateLimiter) nextSlot(now time.Time) time.Time {
+	if l == nil {
+		return now
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.next.Before(now) {
+		return now
+	}
+	return l.next
+}
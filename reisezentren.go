@@ -0,0 +1,246 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-querystring/query"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const reisezentrenAPIPath = "/reisezentren/v1"
+
+// reisezentrenAPIName identifies the Reisezentren API in instrumentation events.
+const reisezentrenAPIName = "reisezentren"
+
+// Endpoint templates of the Reisezentren API.
+const (
+	ReisezentrenEndpoint           = reisezentrenAPIPath + "/reisezentren"
+	ReisezentrumEndpoint           = reisezentrenAPIPath + "/reisezentren/{id}"
+	ReisezentrenNearestEndpoint    = reisezentrenAPIPath + "/reisezentren/loc/{lat}/{lon}"
+	ReisezentrenByLocationEndpoint = reisezentrenAPIPath + "/reisezentren/loc/{lat}/{lon}/{dist}"
+)
+
+// ReisezentrenConfig provides configuration options for the Reisezentren API. Set
+// RateLimitPerMinute to zero if you want to disable rate limiting done in the library.
+type ReisezentrenConfig struct {
+	RateLimitPerMinute int
+}
+
+// Reisezentrum is a DB travel center selling tickets and giving travel advice.
+type Reisezentrum struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	Address    string  `json:"address"`
+	PostalCode string  `json:"postCode"`
+	City       string  `json:"city"`
+	Latitude   float64 `json:"lat"`
+	Longitude  float64 `json:"lon"`
+	// OpeningHours holds the opening hours as sent by the API by day ("mon" to "sun"), e.g.
+	// "06:00-22:30". Days without entry are closed.
+	OpeningHours map[string]string `json:"openingTimes,omitempty"`
+	// OpeningIntervals holds every interval of OpeningHours by day, e.g. two intervals for a
+	// day with lunch break.
+	OpeningIntervals map[string][]OpeningTimes `json:"-"`
+	// Availability holds OpeningHours parsed into the types used by the StationData API. If a
+	// day has several intervals, it spans from the first opening to the last closing.
+	//
+	// Days whose opening hours cannot be parsed are left out of OpeningIntervals and
+	// Availability, their raw value is still available in OpeningHours.
+	Availability Availability `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler and parses the opening hours into OpeningIntervals
+// and Availability.
+func (r *Reisezentrum) UnmarshalJSON(data []byte) error {
+	type plain Reisezentrum
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	decoded.OpeningIntervals, decoded.Availability = parseOpeningHours(decoded.OpeningHours)
+
+	*r = Reisezentrum(decoded)
+	return nil
+}
+
+// parseOpeningHours converts opening hours like {"mon": "06:00-12:00, 13:00-18:00"} into their
+// intervals and an Availability. Unknown days and days with invalid values are skipped.
+func parseOpeningHours(hours map[string]string) (map[string][]OpeningTimes, Availability) {
+	var availability Availability
+	days := map[string]*OpeningTimes{
+		"mon": &availability.Monday,
+		"tue": &availability.Tuesday,
+		"wed": &availability.Wednesday,
+		"thu": &availability.Thursday,
+		"fri": &availability.Friday,
+		"sat": &availability.Saturday,
+		"sun": &availability.Sunday,
+	}
+
+	intervals := map[string][]OpeningTimes{}
+	for day, value := range hours {
+		day = strings.ToLower(day)
+		span, ok := days[day]
+		if !ok {
+			continue
+		}
+
+		dayIntervals, ok := parseOpeningIntervals(value)
+		if !ok || len(dayIntervals) == 0 {
+			continue
+		}
+		intervals[day] = dayIntervals
+		span.FromTime = dayIntervals[0].FromTime
+		span.ToTime = dayIntervals[len(dayIntervals)-1].ToTime
+	}
+	return intervals, availability
+}
+
+// parseOpeningIntervals parses the opening hours of a day like "06:00-12:00, 13:00-18:00".
+func parseOpeningIntervals(value string) ([]OpeningTimes, bool) {
+	var intervals []OpeningTimes
+	for _, interval := range strings.Split(value, ",") {
+		if interval = strings.TrimSpace(interval); interval == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(interval, "-")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, false
+		}
+		intervals = append(intervals, OpeningTimes{FromTime: from, ToTime: to})
+	}
+	return intervals, true
+}
+
+// ReisezentrenRequest is used by Reisezentren to filter the travel centers. If it's not
+// changed, all travel centers are queried.
+type ReisezentrenRequest struct {
+	// Name restricts the result to travel centers whose name contains it.
+	Name string `url:"name,omitempty"`
+}
+
+// ReisezentrenAPI is a struct holding internal information about this API. Its methods can be
+// used to query the API.
+type ReisezentrenAPI struct {
+	client *Client
+}
+
+// Reisezentren returns the travel centers matching the filter.
+func (r *ReisezentrenAPI) Reisezentren(reisezentrenRequest ReisezentrenRequest) ([]Reisezentrum, error) {
+	return r.ReisezentrenContext(context.Background(), reisezentrenRequest)
+}
+
+// ReisezentrenContext is like Reisezentren but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (r *ReisezentrenAPI) ReisezentrenContext(ctx context.Context, reisezentrenRequest ReisezentrenRequest) ([]Reisezentrum, error) {
+	q, err := query.Values(reisezentrenRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s%s/reisezentren", r.client.baseURL(), reisezentrenAPIPath)
+	if len(q) > 0 {
+		url += "?" + q.Encode()
+	}
+
+	var reisezentren []Reisezentrum
+	err = r.get(ctx, &call{
+		operation:  "Reisezentren",
+		endpoint:   ReisezentrenEndpoint,
+		url:        url,
+		attributes: filterAttributes(q),
+	}, &reisezentren)
+	return reisezentren, err
+}
+
+// ReisezentrumByID returns the travel center with the given id.
+func (r *ReisezentrenAPI) ReisezentrumByID(id int) (*Reisezentrum, error) {
+	return r.ReisezentrumByIDContext(context.Background(), id)
+}
+
+// ReisezentrumByIDContext is like ReisezentrumByID but aborts waiting for the rate limiter and
+// the request once ctx is done.
+func (r *ReisezentrenAPI) ReisezentrumByIDContext(ctx context.Context, id int) (*Reisezentrum, error) {
+	url := fmt.Sprintf("%s%s/reisezentren/%d", r.client.baseURL(), reisezentrenAPIPath, id)
+
+	reisezentrum := &Reisezentrum{}
+	err := r.get(ctx, &call{
+		operation:  "ReisezentrumByID",
+		endpoint:   ReisezentrumEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.reisezentrum.id", id)},
+	}, reisezentrum)
+	return reisezentrum, err
+}
+
+// Nearest returns the travel center nearest to the given coordinates.
+func (r *ReisezentrenAPI) Nearest(latitude, longitude float64) (*Reisezentrum, error) {
+	return r.NearestContext(context.Background(), latitude, longitude)
+}
+
+// NearestContext is like Nearest but aborts waiting for the rate limiter and the request once
+// ctx is done.
+func (r *ReisezentrenAPI) NearestContext(ctx context.Context, latitude, longitude float64) (*Reisezentrum, error) {
+	url := fmt.Sprintf("%s%s/reisezentren/loc/%s/%s", r.client.baseURL(), reisezentrenAPIPath,
+		formatCoordinate(latitude), formatCoordinate(longitude))
+
+	reisezentrum := &Reisezentrum{}
+	err := r.get(ctx, &call{
+		operation:  "Nearest",
+		endpoint:   ReisezentrenNearestEndpoint,
+		url:        url,
+		attributes: locationAttributes(latitude, longitude),
+	}, reisezentrum)
+	return reisezentrum, err
+}
+
+// ByLocation returns the travel centers within distance kilometers of the given coordinates.
+func (r *ReisezentrenAPI) ByLocation(latitude, longitude, distance float64) ([]Reisezentrum, error) {
+	return r.ByLocationContext(context.Background(), latitude, longitude, distance)
+}
+
+// ByLocationContext is like ByLocation but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (r *ReisezentrenAPI) ByLocationContext(ctx context.Context, latitude, longitude, distance float64) ([]Reisezentrum, error) {
+	url := fmt.Sprintf("%s%s/reisezentren/loc/%s/%s/%s", r.client.baseURL(), reisezentrenAPIPath,
+		formatCoordinate(latitude), formatCoordinate(longitude), formatCoordinate(distance))
+
+	var reisezentren []Reisezentrum
+	err := r.get(ctx, &call{
+		operation:  "ByLocation",
+		endpoint:   ReisezentrenByLocationEndpoint,
+		url:        url,
+		attributes: append(locationAttributes(latitude, longitude), attribute.Float64("dbapi.location.distance", distance)),
+	}, &reisezentren)
+	return reisezentren, err
+}
+
+func (r *ReisezentrenAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = reisezentrenAPIName
+	c.rateLimitPerMinute = r.client.apiConfig.ReisezentrenConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := r.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(reisezentrenAPIName, resp, data, json.Unmarshal)
+}
+
+// formatCoordinate formats a coordinate or distance for use in a path without exponent.
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func locationAttributes(latitude, longitude float64) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Float64("dbapi.location.latitude", latitude),
+		attribute.Float64("dbapi.location.longitude", longitude),
+	}
+}
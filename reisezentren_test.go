@@ -0,0 +1,103 @@
+package dbapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/reisezentren/v1/", ".json", `{"code":404,"message":"Not Found"}`)
+}
+
+func TestReisezentrenAPI_Reisezentren(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var query string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{captureQuery(&query)},
+	})
+	r := c.ReisezentrenAPI()
+
+	reisezentren, err := r.Reisezentren(ReisezentrenRequest{})
+	assert.Nil(err)
+	assert.Equal("", query)
+	assert.Len(reisezentren, 2)
+
+	dueren := reisezentren[1]
+	assert.Equal("Reisezentrum Düren", dueren.Name)
+	assert.Equal("52349", dueren.PostalCode)
+	assert.Equal(Availability{
+		Monday:    OpeningTimes{FromTime: "07:00", ToTime: "18:00"},
+		Tuesday:   OpeningTimes{FromTime: "07:00", ToTime: "18:00"},
+		Wednesday: OpeningTimes{FromTime: "07:00", ToTime: "18:00"},
+		Thursday:  OpeningTimes{FromTime: "07:00", ToTime: "18:00"},
+		Friday:    OpeningTimes{FromTime: "07:00", ToTime: "18:00"},
+		Saturday:  OpeningTimes{FromTime: "09:00", ToTime: "14:00"},
+	}, dueren.Availability)
+	assert.Equal("07:00-12:30, 13:00-18:00", dueren.OpeningHours["mon"])
+	assert.Equal([]OpeningTimes{
+		{FromTime: "07:00", ToTime: "12:30"},
+		{FromTime: "13:00", ToTime: "18:00"},
+	}, dueren.OpeningIntervals["mon"])
+	assert.Equal([]OpeningTimes{{FromTime: "09:00", ToTime: "14:00"}}, dueren.OpeningIntervals["sat"])
+	assert.NotContains(dueren.OpeningIntervals, "sun")
+
+	_, err = r.Reisezentren(ReisezentrenRequest{Name: "Aachen"})
+	assert.Nil(err)
+	assert.Equal("name=Aachen", query)
+}
+
+func TestReisezentrenAPI_ReisezentrumByID(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	r := c.ReisezentrenAPI()
+
+	aachen, err := r.ReisezentrumByID(1)
+	assert.Nil(err)
+	assert.Equal("Bahnhofplatz 2a", aachen.Address)
+	assert.Equal(50.7678, aachen.Latitude)
+	assert.Equal(6.091499, aachen.Longitude)
+	assert.Equal(OpeningTimes{FromTime: "06:00", ToTime: "21:00"}, aachen.Availability.Monday)
+	assert.Equal(OpeningTimes{FromTime: "09:00", ToTime: "18:00"}, aachen.Availability.Sunday)
+
+	// Invalid opening hours of a day are skipped
+	kaputt, err := r.ReisezentrumByID(3)
+	assert.Nil(err)
+	assert.Equal("ganztags", kaputt.OpeningHours["mon"])
+	assert.NotContains(kaputt.OpeningIntervals, "mon")
+	assert.Equal(OpeningTimes{}, kaputt.Availability.Monday)
+	assert.Equal(OpeningTimes{FromTime: "08:00", ToTime: "12:00"}, kaputt.Availability.Tuesday)
+
+	_, err = r.ReisezentrumByID(4)
+	assert.Equal(&APIError{API: "reisezentren", StatusCode: 404, Message: `{"code":404,"message":"Not Found"}`}, err)
+}
+
+func TestReisezentrenAPI_Location(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	r := c.ReisezentrenAPI()
+
+	nearest, err := r.Nearest(50.7678, 6.0915)
+	assert.Nil(err)
+	assert.Equal(1, nearest.ID)
+
+	reisezentren, err := r.ByLocation(50.7678, 6.0915, 50)
+	assert.Nil(err)
+	assert.Len(reisezentren, 2)
+	assert.Equal(OpeningTimes{}, reisezentren[1].Availability.Sunday)
+}
@@ -0,0 +1,184 @@
+package dbapi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// call describes a single logical GET request against one of the APIs.
+type call struct {
+	api       string
+	operation string
+	endpoint  string
+	url       string
+
+	// accept is the media type requested, application/json if empty.
+	accept string
+
+	// rateLimitPerMinute is the rate limit configured for the API, see keyPool.
+	rateLimitPerMinute int
+
+	// noCache bypasses the response cache for live data, which must neither be served from
+	// the cache nor as stale copy.
+	noCache bool
+
+	// noStale prevents serving stale copies for results that cannot carry the stale flag.
+	noStale bool
+
+	// attributes describe the parameters of the call in traces.
+	attributes []attribute.KeyValue
+
+	// attempts counts the HTTP requests sent for this call.
+	attempts int32
+}
+
+// response is a completely read HTTP response, so it can be cached and decoded
+// independently of the underlying connection.
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+
+	// stale is set if an expired cached response is served because the API is unavailable.
+	stale bool
+	age   time.Duration
+}
+
+// get fetches the call, serving it from the cache or an identical request in flight if configured.
+func (client *Client) get(ctx context.Context, c *call) (resp *response, err error) {
+	ctx, span := client.startCallSpan(ctx, c)
+	defer func() {
+		endCallSpan(span, c, resp, err)
+	}()
+
+	fetch := func(ctx context.Context) (*response, error) {
+		return client.cachedGet(ctx, c)
+	}
+	if client.apiConfig.CoalesceRequests {
+		return client.flights.do(ctx, c.url, fetch)
+	}
+	return fetch(ctx)
+}
+
+// doRequest sends the call to the API and reads the complete response. If the API rejects or
+// rate-limits the key used, the request is retried with another key of the pool.
+func (client *Client) doRequest(ctx context.Context, c *call, header http.Header) (*response, error) {
+	tried := map[*apiKey]bool{}
+	for {
+		key := client.keys.pick(c.api, c.rateLimitPerMinute, tried)
+		if key == nil {
+			return nil, errors.New("no API token given")
+		}
+
+		resp, err := client.attempt(ctx, c, header, key)
+		if err == nil && client.keys.renew(key, resp) {
+			resp, err = client.attempt(ctx, c, header, key)
+		}
+		tried[key] = true
+		if err != nil || !client.keys.record(key, resp) || !client.keys.available(tried) {
+			return resp, err
+		}
+	}
+}
+
+// attempt sends a single request with the given key, applying its rate limiting.
+func (client *Client) attempt(ctx context.Context, c *call, header http.Header, key *apiKey) (*response, error) {
+	req, err := http.NewRequest("GET", c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	accept := c.accept
+	if accept == "" {
+		accept = "application/json"
+	}
+	req.Header.Set("Accept", accept)
+
+	instrumentation := client.instrumentation()
+	info := RequestInfo{
+		API:      c.api,
+		Endpoint: c.endpoint,
+		Method:   req.Method,
+		Attempt:  int(atomic.AddInt32(&c.attempts, 1)),
+	}
+
+	ctx, span := client.startAttemptSpan(ctx, req, info)
+	ctx = withOperation(ctx, Operation{
+		API:      c.api,
+		Name:     c.operation,
+		Endpoint: c.endpoint,
+		Attempt:  info.Attempt,
+	})
+	req = req.WithContext(ctx)
+
+	// Fail fast while the API is known to be unavailable
+	if err := client.breaker.allow(); err != nil {
+		endAttemptSpan(span, nil, err)
+		return nil, err
+	}
+
+	// Throttle API in case a tier was specified
+	if limiter := key.limiter(c.api, c.rateLimitPerMinute); limiter != nil {
+		waitStart := time.Now()
+		if err := limiter.wait(ctx); err != nil {
+			client.breaker.done(ctx, nil, err)
+			endAttemptSpan(span, nil, err)
+			return nil, err
+		}
+		wait := time.Since(waitStart)
+		instrumentation.RateLimitWaited(info, wait)
+		span.SetAttributes(attribute.Float64("dbapi.rate_limit.wait_seconds", wait.Seconds()))
+	}
+
+	// Failing to obtain credentials says nothing about the availability of the API
+	if err := key.auth.Authenticate(req); err != nil {
+		client.breaker.release()
+		endAttemptSpan(span, nil, err)
+		return nil, err
+	}
+	client.injectTraceContext(ctx, req)
+
+	instrumentation.RequestStarted(info)
+	start := time.Now()
+
+	resp, err := client.readResponse(req)
+	client.breaker.done(ctx, resp, err)
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.statusCode
+	}
+	duration := time.Since(start)
+	instrumentation.RequestFinished(info, statusCode, duration, err)
+	client.logRequest(ctx, req, info, resp, duration, err)
+	endAttemptSpan(span, resp, err)
+
+	return resp, err
+}
+
+func (client *Client) readResponse(req *http.Request) (*response, error) {
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       body,
+	}, nil
+}
@@ -0,0 +1,201 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StationDataService covers all operations of the StationData API. It is implemented by
+// StationDataAPI, CachingStationData and the in-memory dbapitest.MemoryStationData, so code
+// depending on the interface can be tested without an HTTP server.
+type StationDataService interface {
+	StationByID(id int) (*StationDataStationResponse, error)
+	StationByIDContext(ctx context.Context, id int) (*StationDataStationResponse, error)
+	StationByFilter(stationRequest StationDataStationRequest) (*StationDataStationResponse, error)
+	StationByFilterContext(ctx context.Context, stationRequest StationDataStationRequest) (*StationDataStationResponse, error)
+	StationAll() (*StationDataStationResponse, error)
+	StationAllContext(ctx context.Context) (*StationDataStationResponse, error)
+	StationsByIDs(ctx context.Context, ids []int) (map[int]Station, map[int]error)
+
+	SZentralenByID(id int) (*StationDataSZentralenResponse, error)
+	SZentralenByIDContext(ctx context.Context, id int) (*StationDataSZentralenResponse, error)
+	SZentralenByFilter(szentralenRequest StationDataSZentralenRequest) (*StationDataSZentralenResponse, error)
+	SZentralenByFilterContext(ctx context.Context, szentralenRequest StationDataSZentralenRequest) (*StationDataSZentralenResponse, error)
+	SZentralenAll() (*StationDataSZentralenResponse, error)
+	SZentralenAllContext(ctx context.Context) (*StationDataSZentralenResponse, error)
+}
+
+var (
+	_ StationDataService = (*StationDataAPI)(nil)
+	_ StationDataService = (*CachingStationData)(nil)
+)
+
+// CachingStationData is a StationDataService that keeps successful responses of another
+// StationDataService in a Cache for a fixed TTL. Errors and stale responses are not cached.
+// Unlike CacheConfig, which caches the HTTP responses of a Client, it works with any
+// implementation of the interface.
+type CachingStationData struct {
+	next    StationDataService
+	backend Cache
+	ttl     time.Duration
+}
+
+// NewCachingStationData creates a CachingStationData in front of next. If backend is nil,
+// a MemoryCache holding up to 1000 responses is used.
+func NewCachingStationData(next StationDataService, backend Cache, ttl time.Duration) *CachingStationData {
+	if backend == nil {
+		backend = NewMemoryCache(1000)
+	}
+	return &CachingStationData{
+		next:    next,
+		backend: backend,
+		ttl:     ttl,
+	}
+}
+
+// StationByID implements StationDataService.
+func (c *CachingStationData) StationByID(id int) (*StationDataStationResponse, error) {
+	return c.StationByIDContext(context.Background(), id)
+}
+
+// StationByIDContext implements StationDataService.
+func (c *CachingStationData) StationByIDContext(ctx context.Context, id int) (*StationDataStationResponse, error) {
+	return cached(c, stationCacheKey(id), func() (*StationDataStationResponse, error) {
+		return c.next.StationByIDContext(ctx, id)
+	})
+}
+
+// StationByFilter implements StationDataService.
+func (c *CachingStationData) StationByFilter(stationRequest StationDataStationRequest) (*StationDataStationResponse, error) {
+	return c.StationByFilterContext(context.Background(), stationRequest)
+}
+
+// StationByFilterContext implements StationDataService.
+func (c *CachingStationData) StationByFilterContext(ctx context.Context, stationRequest StationDataStationRequest) (*StationDataStationResponse, error) {
+	return cached(c, fmt.Sprintf("stationdata:stations:%+v", stationRequest), func() (*StationDataStationResponse, error) {
+		return c.next.StationByFilterContext(ctx, stationRequest)
+	})
+}
+
+// StationAll implements StationDataService.
+func (c *CachingStationData) StationAll() (*StationDataStationResponse, error) {
+	return c.StationByFilter(StationDataStationRequest{})
+}
+
+// StationAllContext implements StationDataService.
+func (c *CachingStationData) StationAllContext(ctx context.Context) (*StationDataStationResponse, error) {
+	return c.StationByFilterContext(ctx, StationDataStationRequest{})
+}
+
+// StationsByIDs implements StationDataService. Cached stations are returned directly, the
+// remaining ids are fetched with a single StationsByIDs call to the underlying service and
+// cached as if they had been queried by StationByID.
+func (c *CachingStationData) StationsByIDs(ctx context.Context, ids []int) (map[int]Station, map[int]error) {
+	stations := map[int]Station{}
+
+	var missing []int
+	for _, id := range ids {
+		if _, ok := stations[id]; ok {
+			continue
+		}
+		sdr := &StationDataStationResponse{}
+		if c.load(stationCacheKey(id), sdr) && len(sdr.Result) > 0 {
+			stations[id] = sdr.Result[0]
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return stations, map[int]error{}
+	}
+
+	fetched, errs := c.next.StationsByIDs(ctx, missing)
+	for id, station := range fetched {
+		stations[id] = station
+		c.store(stationCacheKey(id), &StationDataStationResponse{
+			Limit:  1,
+			Total:  1,
+			Result: []Station{station},
+		})
+	}
+	return stations, errs
+}
+
+// SZentralenByID implements StationDataService.
+func (c *CachingStationData) SZentralenByID(id int) (*StationDataSZentralenResponse, error) {
+	return c.SZentralenByIDContext(context.Background(), id)
+}
+
+// SZentralenByIDContext implements StationDataService.
+func (c *CachingStationData) SZentralenByIDContext(ctx context.Context, id int) (*StationDataSZentralenResponse, error) {
+	return cached(c, fmt.Sprintf("stationdata:szentralen/%d", id), func() (*StationDataSZentralenResponse, error) {
+		return c.next.SZentralenByIDContext(ctx, id)
+	})
+}
+
+// SZentralenByFilter implements StationDataService.
+func (c *CachingStationData) SZentralenByFilter(szentralenRequest StationDataSZentralenRequest) (*StationDataSZentralenResponse, error) {
+	return c.SZentralenByFilterContext(context.Background(), szentralenRequest)
+}
+
+// SZentralenByFilterContext implements StationDataService.
+func (c *CachingStationData) SZentralenByFilterContext(ctx context.Context, szentralenRequest StationDataSZentralenRequest) (*StationDataSZentralenResponse, error) {
+	return cached(c, fmt.Sprintf("stationdata:szentralen:%+v", szentralenRequest), func() (*StationDataSZentralenResponse, error) {
+		return c.next.SZentralenByFilterContext(ctx, szentralenRequest)
+	})
+}
+
+// SZentralenAll implements StationDataService.
+func (c *CachingStationData) SZentralenAll() (*StationDataSZentralenResponse, error) {
+	return c.SZentralenByFilter(StationDataSZentralenRequest{})
+}
+
+// SZentralenAllContext implements StationDataService.
+func (c *CachingStationData) SZentralenAllContext(ctx context.Context) (*StationDataSZentralenResponse, error) {
+	return c.SZentralenByFilterContext(ctx, StationDataSZentralenRequest{})
+}
+
+func stationCacheKey(id int) string {
+	return fmt.Sprintf("stationdata:stations/%d", id)
+}
+
+// cached returns the response stored under key or calls fetch and stores its result.
+func cached[T any](c *CachingStationData, key string, fetch func() (*T, error)) (*T, error) {
+	resp := new(T)
+	if c.load(key, resp) {
+		return resp, nil
+	}
+
+	resp, err := fetch()
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	if stale, ok := any(resp).(interface{ isStale() bool }); !ok || !stale.isStale() {
+		c.store(key, resp)
+	}
+	return resp, nil
+}
+
+func (c *CachingStationData) load(key string, data interface{}) bool {
+	entry, ok := c.backend.Get(key)
+	if !ok || !entry.fresh(time.Now()) {
+		return false
+	}
+	return json.Unmarshal(entry.Body, data) == nil
+}
+
+func (c *CachingStationData) store(key string, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	c.backend.Set(key, &CacheEntry{
+		Body:     body,
+		StoredAt: now,
+		Expires:  now.Add(c.ttl),
+	})
+}
@@ -0,0 +1,89 @@
+package dbapi
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingStationData(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var requests int32
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&requests, 1)
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+
+	var s StationDataService = NewCachingStationData(c.StationDataAPI(), nil, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		stationResp, err := s.StationByID(1)
+		assert.Nil(err)
+		assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+
+		szResp, err := s.SZentralenAll()
+		assert.Nil(err)
+		assert.NotEmpty(szResp.Result)
+	}
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+
+	// Errors are not cached
+	_, err := s.StationByID(2)
+	assert.NotNil(err)
+	_, err = s.StationByID(2)
+	assert.NotNil(err)
+	assert.Equal(int32(4), atomic.LoadInt32(&requests))
+
+	// Stations fetched in a batch are cached for StationByID
+	s = NewCachingStationData(c.StationDataAPI(), nil, time.Minute)
+	stations, errs := s.StationsByIDs(context.Background(), []int{1, 2})
+	assert.Equal("Aachen Hbf", stations[1].Name)
+	assert.Len(errs, 1)
+	assert.Equal(int32(6), atomic.LoadInt32(&requests))
+
+	stationResp, err := s.StationByIDContext(context.Background(), 1)
+	assert.Nil(err)
+	assert.Equal(stations[1], stationResp.Result[0])
+	assert.Equal(int32(6), atomic.LoadInt32(&requests))
+
+	stations, errs = s.StationsByIDs(context.Background(), []int{1})
+	assert.Len(stations, 1)
+	assert.Len(errs, 0)
+	assert.Equal(int32(6), atomic.LoadInt32(&requests))
+}
+
+func TestCachingStationData_Expiry(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	backend := NewMemoryCache(0)
+	s := NewCachingStationData(New("SomeFakeToken", Config{}).StationDataAPI(), backend, time.Millisecond)
+
+	_, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.Equal(1, backend.Len())
+
+	entry, _ := backend.Get(stationCacheKey(1))
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = s.StationByID(1)
+	assert.Nil(err)
+	refreshed, _ := backend.Get(stationCacheKey(1))
+	assert.True(refreshed.StoredAt.After(entry.StoredAt))
+}
@@ -0,0 +1,106 @@
+package dbapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultStaleRefreshInterval = 30 * time.Second
+
+// Staleness is part of responses that can be served from the cache while the API is
+// unavailable, see CacheConfig.ServeStale.
+type Staleness struct {
+	// Stale is set if the response is an outdated copy from the cache, served because the
+	// API failed.
+	Stale bool `json:"-" xml:"-"`
+	// Age is the time since a stale response was last received from or validated by the API.
+	Age time.Duration `json:"-" xml:"-"`
+}
+
+type staleMarker interface {
+	markStale(age time.Duration)
+}
+
+func (s *Staleness) markStale(age time.Duration) {
+	s.Stale = true
+	s.Age = age
+}
+
+func (s *Staleness) isStale() bool {
+	return s.Stale
+}
+
+// canMarkStale reports whether data can carry the stale flag, so a stale copy may be
+// decoded into it.
+func canMarkStale(data interface{}) bool {
+	_, ok := data.(staleMarker)
+	return ok
+}
+
+// unavailable reports whether a request failed because of the API rather than the caller.
+func unavailable(ctx context.Context, resp *response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return resp.statusCode >= 500 || resp.statusCode == http.StatusTooManyRequests
+}
+
+// staleRefreshes tracks the background refreshes of stale cache entries.
+type staleRefreshes struct {
+	mu          sync.Mutex
+	lastAttempt map[string]time.Time
+	running     map[string]bool
+}
+
+// start reports whether a refresh of url may be started and marks it as running. Attempts
+// older than interval no longer matter and are dropped, so lastAttempt only holds the urls
+// refreshed recently.
+func (r *staleRefreshes) start(url string, interval time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastAttempt == nil {
+		r.lastAttempt = map[string]time.Time{}
+		r.running = map[string]bool{}
+	}
+	for attempted, at := range r.lastAttempt {
+		if !r.running[attempted] && time.Since(at) >= interval {
+			delete(r.lastAttempt, attempted)
+		}
+	}
+	if r.running[url] || time.Since(r.lastAttempt[url]) < interval {
+		return false
+	}
+	r.running[url] = true
+	r.lastAttempt[url] = time.Now()
+	return true
+}
+
+func (r *staleRefreshes) finish(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.running, url)
+}
+
+// refreshStale fetches c in the background to replace the stale cache entry once the API
+// recovered. The refresh never serves stale responses itself.
+func (client *Client) refreshStale(c *call) {
+	interval := client.apiConfig.CacheConfig.StaleRefreshInterval
+	if interval <= 0 {
+		interval = defaultStaleRefreshInterval
+	}
+	if !client.staleRefreshes.start(c.url, interval) {
+		return
+	}
+
+	refresh := *c
+	refresh.noStale = false
+	refresh.attempts = 0
+	go func() {
+		defer client.staleRefreshes.finish(c.url)
+		client.fetchCached(context.Background(), &refresh, false)
+	}()
+}
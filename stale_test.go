@@ -0,0 +1,201 @@
+package dbapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationDataAPI_ServeStale(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var failures int32
+	backend := NewMemoryCache(10)
+	c := New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{
+			Backend:              backend,
+			ServeStale:           true,
+			StaleRefreshInterval: time.Nanosecond,
+		},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if atomic.AddInt32(&failures, -1) >= 0 {
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Body:       io.NopCloser(strings.NewReader("Service Unavailable")),
+						Request:    req,
+					}, nil
+				}
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+	s := c.StationDataAPI()
+
+	stationResp, err := s.StationByID(1)
+	assert.Nil(err)
+	assert.False(stationResp.Stale)
+
+	entry, ok := backend.Get(APIURL + "/stada/v2/stations/1")
+	assert.True(ok)
+	storedAt := entry.StoredAt
+
+	// The next request fails, the background refresh succeeds
+	atomic.StoreInt32(&failures, 1)
+	stationResp, err = s.StationByID(1)
+	assert.Nil(err)
+	assert.True(stationResp.Stale)
+	assert.True(stationResp.Age > 0)
+	assert.Equal("Aachen Hbf", stationResp.Result[0].Name)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		entry, _ = backend.Get(APIURL + "/stada/v2/stations/1")
+		if entry.StoredAt.After(storedAt) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(entry.StoredAt.After(storedAt), "stale entry must be refreshed in the background")
+
+	// Responses older than MaxStaleAge are not served
+	c = New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{
+			Backend:     backend,
+			ServeStale:  true,
+			MaxStaleAge: time.Nanosecond,
+		},
+		Middleware: c.apiConfig.Middleware,
+	})
+	atomic.StoreInt32(&failures, 1)
+	_, err = c.StationDataAPI().StationByID(1)
+	assert.EqualError(err, "Service Unavailable")
+}
+
+func TestServeStale_UnflaggedResults(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var failures, requests int32
+	c := New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{
+			Backend:    NewMemoryCache(10),
+			ServeStale: true,
+		},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&requests, 1)
+				if atomic.AddInt32(&failures, -1) >= 0 {
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Body:       io.NopCloser(strings.NewReader("Service Unavailable")),
+						Request:    req,
+					}, nil
+				}
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+
+	// Lists cannot be flagged as stale, so the error is returned instead of a stale copy
+	_, err := c.PhotosAPI().Stations("de", PhotosStationsRequest{})
+	assert.Nil(err)
+	atomic.StoreInt32(&failures, 1)
+	_, err = c.PhotosAPI().Stations("de", PhotosStationsRequest{})
+	assert.Equal(&APIError{API: "photos", StatusCode: 503, Message: "Service Unavailable"}, err)
+
+	// FaSta responses are never taken from the cache
+	_, err = c.FaStaAPI().Facilities(FaStaFacilitiesRequest{})
+	assert.Nil(err)
+	atomic.StoreInt32(&failures, 1)
+	_, err = c.FaStaAPI().Facilities(FaStaFacilitiesRequest{})
+	assert.Equal(&APIError{API: "fasta", StatusCode: 503, Message: "Service Unavailable"}, err)
+
+	atomic.StoreInt32(&requests, 0)
+	_, err = c.FaStaAPI().StationFacilities(1866)
+	assert.Nil(err)
+	_, err = c.FaStaAPI().StationFacilities(1866)
+	assert.Nil(err)
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestStaleRefreshes_Prune(t *testing.T) {
+	assert := assert.New(t)
+
+	var refreshes staleRefreshes
+	assert.True(refreshes.start("a", 10*time.Millisecond))
+	assert.True(refreshes.start("b", 10*time.Millisecond))
+	assert.False(refreshes.start("a", 10*time.Millisecond))
+	refreshes.finish("a")
+
+	time.Sleep(20 * time.Millisecond)
+
+	// Only the running refresh is kept besides the new one
+	assert.True(refreshes.start("c", 10*time.Millisecond))
+	assert.Len(refreshes.lastAttempt, 2)
+	assert.Contains(refreshes.lastAttempt, "b")
+	assert.Contains(refreshes.lastAttempt, "c")
+}
+
+func TestTimetablesAPI_ServeStaleRefresh(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var failures int32
+	accepts := make(chan string, 10)
+	c := New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{
+			Backend:              NewMemoryCache(10),
+			ServeStale:           true,
+			StaleRefreshInterval: time.Nanosecond,
+		},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				accepts <- req.Header.Get("Accept")
+				if atomic.AddInt32(&failures, -1) >= 0 {
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Body:       io.NopCloser(strings.NewReader("Service Unavailable")),
+						Request:    req,
+					}, nil
+				}
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+	tt := c.TimetablesAPI()
+	date := time.Date(2022, 7, 14, 0, 0, 0, 0, berlin)
+
+	_, err := tt.Plan(8000105, date, 12)
+	assert.Nil(err)
+	assert.Equal("application/xml", <-accepts)
+
+	atomic.StoreInt32(&failures, 1)
+	plan, err := tt.Plan(8000105, date, 12)
+	assert.Nil(err)
+	assert.True(plan.Stale)
+	assert.Equal("application/xml", <-accepts)
+
+	// The background refresh requests the same media type
+	select {
+	case accept := <-accepts:
+		assert.Equal("application/xml", accept)
+	case <-time.After(5 * time.Second):
+		assert.Fail("stale entry must be refreshed in the background")
+	}
+}
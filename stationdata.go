@@ -1,18 +1,32 @@
 package dbapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"time"
+	"sync"
 
 	"github.com/google/go-querystring/query"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const stadaAPIPath = "/stada/v2"
 
+// stationDataAPIName identifies the StationData API in instrumentation events.
+const stationDataAPIName = "stationdata"
+
+const defaultBatchWorkers = 4
+
+// Endpoint templates of the StationData API. They can be used to configure per-endpoint
+// settings such as CacheConfig.EndpointTTLs.
+const (
+	StationDataStationsEndpoint      = stadaAPIPath + "/stations"
+	StationDataStationByIDEndpoint   = stadaAPIPath + "/stations/{id}"
+	StationDataSZentralenEndpoint    = stadaAPIPath + "/szentralen"
+	StationDataSZentraleByIDEndpoint = stadaAPIPath + "/szentralen/{id}"
+)
+
 // MailingAddress holds the postal address of the station.
 type MailingAddress struct {
 	City        string `json:"city,omitempty"`
@@ -154,6 +168,8 @@ type Station struct {
 
 // StationDataStationResponse holds meta information about the response and the actual station set.
 type StationDataStationResponse struct {
+	Staleness
+
 	Offset int       `json:"offset,omitempty"`
 	Total  int       `json:"total,omitempty"`
 	Limit  int       `json:"limit,omitempty"`
@@ -181,6 +197,8 @@ type StationDataSZentralenRequest struct {
 
 // StationDataStationResponse holds meta information about the response and the actual station set.
 type StationDataSZentralenResponse struct {
+	Staleness
+
 	Offset int         `json:"offset,omitempty"`
 	Total  int         `json:"total,omitempty"`
 	Limit  int         `json:"limit,omitempty"`
@@ -209,9 +227,7 @@ type StationDataErrorResponse struct {
 // StationDataAPI is a struct holding internal information about this API. Its methods can be used
 // to query the API.
 type StationDataAPI struct {
-	client                *Client
-	rateThrottleTicker    *time.Ticker
-	firstRequestProcessed bool
+	client *Client
 }
 
 func (e *StationDataRateErrorResponse) Error() string {
@@ -225,21 +241,21 @@ func (e *StationDataErrorResponse) Error() string {
 // StationByID returns station information for the given id or an error if the
 // id is invalid, rate limiting or some other error occurred.
 func (s *StationDataAPI) StationByID(id int) (*StationDataStationResponse, error) {
-	url := fmt.Sprintf("%s%s/stations/%d", APIURL, stadaAPIPath, id)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "application/json")
+	return s.StationByIDContext(context.Background(), id)
+}
 
-	resp, err := s.sendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+// StationByIDContext is like StationByID but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (s *StationDataAPI) StationByIDContext(ctx context.Context, id int) (*StationDataStationResponse, error) {
+	url := fmt.Sprintf("%s%s/stations/%d", s.client.baseURL(), stadaAPIPath, id)
 
 	sdr := &StationDataStationResponse{}
-	err = s.processResponse(resp, sdr)
+	err := s.get(ctx, &call{
+		operation:  "StationByID",
+		endpoint:   StationDataStationByIDEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.station.id", id)},
+	}, sdr)
 	return sdr, err
 }
 
@@ -247,26 +263,26 @@ func (s *StationDataAPI) StationByID(id int) (*StationDataStationResponse, error
 // id is invalid, rate limiting or some other error occurred. If the StationDataStationRequest is
 // not set, all stations are returned (max 10.000) - same as All().
 func (s *StationDataAPI) StationByFilter(stationRequest StationDataStationRequest) (*StationDataStationResponse, error) {
-	q, err := query.Values(stationRequest)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s%s/stations?%s", APIURL, stadaAPIPath, q.Encode())
+	return s.StationByFilterContext(context.Background(), stationRequest)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// StationByFilterContext is like StationByFilter but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (s *StationDataAPI) StationByFilterContext(ctx context.Context, stationRequest StationDataStationRequest) (*StationDataStationResponse, error) {
+	q, err := query.Values(stationRequest)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.sendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+	url := fmt.Sprintf("%s%s/stations?%s", s.client.baseURL(), stadaAPIPath, q.Encode())
 
 	sdr := &StationDataStationResponse{}
-	err = s.processResponse(resp, sdr)
+	err = s.get(ctx, &call{
+		operation:  "StationByFilter",
+		endpoint:   StationDataStationsEndpoint,
+		url:        url,
+		attributes: filterAttributes(q),
+	}, sdr)
 	return sdr, err
 }
 
@@ -276,24 +292,30 @@ func (s *StationDataAPI) StationAll() (*StationDataStationResponse, error) {
 	return s.StationByFilter(StationDataStationRequest{})
 }
 
+// StationAllContext is like StationAll but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (s *StationDataAPI) StationAllContext(ctx context.Context) (*StationDataStationResponse, error) {
+	return s.StationByFilterContext(ctx, StationDataStationRequest{})
+}
+
 // SZentralenByID returns station information for the given id or an error if the
 // id is invalid, rate limiting or some other error occurred.
 func (s *StationDataAPI) SZentralenByID(id int) (*StationDataSZentralenResponse, error) {
-	url := fmt.Sprintf("%s%s/szentralen/%d", APIURL, stadaAPIPath, id)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "application/json")
+	return s.SZentralenByIDContext(context.Background(), id)
+}
 
-	resp, err := s.sendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+// SZentralenByIDContext is like SZentralenByID but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (s *StationDataAPI) SZentralenByIDContext(ctx context.Context, id int) (*StationDataSZentralenResponse, error) {
+	url := fmt.Sprintf("%s%s/szentralen/%d", s.client.baseURL(), stadaAPIPath, id)
 
 	sdr := &StationDataSZentralenResponse{}
-	err = s.processResponse(resp, sdr)
+	err := s.get(ctx, &call{
+		operation:  "SZentralenByID",
+		endpoint:   StationDataSZentraleByIDEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.szentrale.id", id)},
+	}, sdr)
 	return sdr, err
 }
 
@@ -301,26 +323,26 @@ func (s *StationDataAPI) SZentralenByID(id int) (*StationDataSZentralenResponse,
 // id is invalid, rate limiting or some other error occurred. If the StationDataSZentralenRequest is
 // not set, all szentralen are returned (max 10.000) - same as All().
 func (s *StationDataAPI) SZentralenByFilter(szentralenRequest StationDataSZentralenRequest) (*StationDataSZentralenResponse, error) {
-	q, err := query.Values(szentralenRequest)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s%s/szentralen?%s", APIURL, stadaAPIPath, q.Encode())
+	return s.SZentralenByFilterContext(context.Background(), szentralenRequest)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// SZentralenByFilterContext is like SZentralenByFilter but aborts waiting for the rate limiter
+// and the request once ctx is done.
+func (s *StationDataAPI) SZentralenByFilterContext(ctx context.Context, szentralenRequest StationDataSZentralenRequest) (*StationDataSZentralenResponse, error) {
+	q, err := query.Values(szentralenRequest)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.sendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+	url := fmt.Sprintf("%s%s/szentralen?%s", s.client.baseURL(), stadaAPIPath, q.Encode())
 
 	sdr := &StationDataSZentralenResponse{}
-	err = s.processResponse(resp, sdr)
+	err = s.get(ctx, &call{
+		operation:  "SZentralenByFilter",
+		endpoint:   StationDataSZentralenEndpoint,
+		url:        url,
+		attributes: filterAttributes(q),
+	}, sdr)
 	return sdr, err
 }
 
@@ -330,59 +352,134 @@ func (s *StationDataAPI) SZentralenAll() (*StationDataSZentralenResponse, error)
 	return s.SZentralenByFilter(StationDataSZentralenRequest{})
 }
 
-// StationByFilter returns a list of station information by the given filter or an error if the
-func (s *StationDataAPI) limitRate() {
-	// Throttle API in case a tier was specified
-	if s.rateThrottleTicker != nil && s.firstRequestProcessed {
-		<-s.rateThrottleTicker.C
-	}
-	s.firstRequestProcessed = true
+// SZentralenAllContext is like SZentralenAll but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (s *StationDataAPI) SZentralenAllContext(ctx context.Context) (*StationDataSZentralenResponse, error) {
+	return s.SZentralenByFilterContext(ctx, StationDataSZentralenRequest{})
 }
 
-func (s *StationDataAPI) sendRequest(req *http.Request) (*http.Response, error) {
-	if s.client.APIToken == "" {
-		return nil, errors.New("no API token given")
+// StationsByIDs returns station information for all given ids. Duplicate ids are queried only
+// once and the requests are distributed over StationDataConfig.BatchWorkers concurrent workers
+// sharing the rate limiter of the API. Stations that could be fetched are returned in the first
+// map, errors are reported per id in the second one. If ctx is done before all ids have been
+// processed, the stations fetched so far are returned and the remaining ids fail with ctx.Err().
+func (s *StationDataAPI) StationsByIDs(ctx context.Context, ids []int) (map[int]Station, map[int]error) {
+	stations := map[int]Station{}
+	errs := map[int]error{}
+
+	var unique []int
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
 	}
 
-	s.limitRate()
+	workers := s.client.apiConfig.StationDataConfig.BatchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	if workers > len(unique) {
+		workers = len(unique)
+	}
 
-	req.Header.Set("Authorization", "Bearer "+s.client.APIToken)
-	return s.client.httpClient.Do(req)
-}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan int)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				err := ctx.Err()
+				var station Station
+				if err == nil {
+					station, err = s.stationByID(ctx, id)
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[id] = err
+				} else {
+					stations[id] = station
+				}
+				mu.Unlock()
+			}
+		}()
+	}
 
-func (s *StationDataAPI) processResponse(resp *http.Response, data interface{}) (err error) {
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil && err == nil {
-			err = cerr
+	for i, id := range unique {
+		select {
+		case queue <- id:
+			continue
+		case <-ctx.Done():
 		}
-	}()
 
-	switch resp.StatusCode {
+		mu.Lock()
+		for _, remaining := range unique[i:] {
+			errs[remaining] = ctx.Err()
+		}
+		mu.Unlock()
+		break
+	}
+	close(queue)
+	wg.Wait()
+
+	return stations, errs
+}
+
+func (s *StationDataAPI) stationByID(ctx context.Context, id int) (Station, error) {
+	sdr, err := s.StationByIDContext(ctx, id)
+	if err != nil {
+		return Station{}, err
+	}
+	if len(sdr.Result) == 0 {
+		return Station{}, fmt.Errorf("station %d not found", id)
+	}
+	return sdr.Result[0], nil
+}
+
+func (s *StationDataAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = stationDataAPIName
+	c.rateLimitPerMinute = s.client.apiConfig.StationDataConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := s.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return s.processResponse(resp, data)
+}
+
+func (s *StationDataAPI) processResponse(resp *response, data interface{}) error {
+	switch resp.statusCode {
 	case 200:
-		err := json.NewDecoder(resp.Body).Decode(data)
-		if err != nil {
+		if err := json.Unmarshal(resp.body, data); err != nil {
 			return err
 		}
+		if resp.stale {
+			if marker, ok := data.(staleMarker); ok {
+				marker.markStale(resp.age)
+			}
+		}
 		return nil
 	case 404, 500:
 		stationDataErrorResponse := StationDataErrorResponse{}
-		err := json.NewDecoder(resp.Body).Decode(&stationDataErrorResponse)
+		err := json.Unmarshal(resp.body, &stationDataErrorResponse)
 		if err != nil {
 			return err
 		}
-		return nil
+		return &stationDataErrorResponse
 	case 429:
 		stationDataRateErrorResponse := StationDataRateErrorResponse{}
-		err := json.NewDecoder(resp.Body).Decode(&stationDataRateErrorResponse)
+		err := json.Unmarshal(resp.body, &stationDataRateErrorResponse)
 		if err != nil {
 			return err
 		}
-		return nil
+		return &stationDataRateErrorResponse
 	default:
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-		return errors.New(string(body))
+		return errors.New(string(resp.body))
 	}
 }
@@ -1,6 +1,7 @@
 package dbapi
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -16,7 +17,12 @@ func init() {
 
 		fmt.Println("Sending data from file ", filename)
 
-		dat, _ := ioutil.ReadFile(filename)
+		dat, err := ioutil.ReadFile(filename)
+		if err != nil {
+			writer.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(writer, `{"errNo":404,"errMsg":"Not Found"}`)
+			return
+		}
 
 		fmt.Fprint(writer, string(dat))
 	})
@@ -64,6 +70,10 @@ func TestStationDataAPI_StationByID(t *testing.T) {
 	assert.Equal("Point", station.Ril100Identifiers[0].GeographicCoordinates.Type)
 	assert.Equal(6.091201396, station.Ril100Identifiers[0].GeographicCoordinates.Coordinates[0])
 	assert.Equal(50.767558188, station.Ril100Identifiers[0].GeographicCoordinates.Coordinates[1])
+
+	// Error responses are returned as errors
+	_, err := s.StationByID(99)
+	assert.Equal(&StationDataErrorResponse{ErrNo: 404, ErrMsg: "Not Found"}, err)
 }
 
 func TestStationDataAPI_StationByFilter(t *testing.T) {
@@ -120,6 +130,35 @@ func TestStationDataAPI_SZentralenAll(t *testing.T) {
 	assert.Equal(30, szentralenResp.Total)
 }
 
+func TestStationDataAPI_StationsByIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{
+		StationDataConfig: StationDataConfig{BatchWorkers: 2},
+	})
+	s := c.StationDataAPI()
+
+	stations, errs := s.StationsByIDs(context.Background(), []int{1, 99, 1})
+
+	assert.Len(stations, 1)
+	assert.Equal("Aachen Hbf", stations[1].Name)
+	assert.Len(errs, 1)
+	assert.Equal(&StationDataErrorResponse{ErrNo: 404, ErrMsg: "Not Found"}, errs[99])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stations, errs = s.StationsByIDs(ctx, []int{1, 99})
+
+	assert.Len(stations, 0)
+	assert.Equal(context.Canceled, errs[1])
+	assert.Equal(context.Canceled, errs[99])
+}
+
 func TestRateLimiter(t *testing.T) {
 	assert := assert.New(t)
 
@@ -128,7 +167,7 @@ func TestRateLimiter(t *testing.T) {
 	APIURL = "http://" + serverAddr + "/"
 
 	c := New("SomeFakeToken", Config{
-		StationDataConfig: StationDataConfig{rateLimitPerMinute: 20}, // Should sleep for ~3 seconds
+		StationDataConfig: StationDataConfig{RateLimitPerMinute: 20}, // Should sleep for ~3 seconds
 	})
 	s := c.StationDataAPI()
 
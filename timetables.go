@@ -0,0 +1,281 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Berlin must be available on systems without zoneinfo
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const timetablesAPIPath = "/timetables/v1"
+
+// timetablesAPIName identifies the Timetables API in instrumentation events.
+const timetablesAPIName = "timetables"
+
+// Endpoint templates of the Timetables API.
+const (
+	TimetablesPlanEndpoint          = timetablesAPIPath + "/plan/{evaNo}/{date}/{hour}"
+	TimetablesFullChangesEndpoint   = timetablesAPIPath + "/fchg/{evaNo}"
+	TimetablesRecentChangesEndpoint = timetablesAPIPath + "/rchg/{evaNo}"
+)
+
+// timetableTimeLayout is the YYMMddHHmm format used for all times of the Timetables API.
+const timetableTimeLayout = "0601021504"
+
+// berlin is the time zone of all times returned by the DB APIs.
+var berlin = mustLoadLocation("Europe/Berlin")
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+// TimetablesConfig provides configuration options for the Timetables API. Set RateLimitPerMinute
+// to zero if you want to disable rate limiting done in the library.
+type TimetablesConfig struct {
+	RateLimitPerMinute int
+}
+
+// TimetableTime is a point in time in Europe/Berlin as sent by the Timetables API. The zero
+// value means the attribute was not set.
+type TimetableTime struct {
+	time.Time
+}
+
+// UnmarshalXMLAttr implements xml.UnmarshalerAttr.
+func (t *TimetableTime) UnmarshalXMLAttr(attr xml.Attr) error {
+	if attr.Value == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.ParseInLocation(timetableTimeLayout, attr.Value, berlin)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalXMLAttr implements xml.MarshalerAttr.
+func (t TimetableTime) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if t.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: t.In(berlin).Format(timetableTimeLayout)}, nil
+}
+
+// EventStatus is the status of an arrival or departure.
+type EventStatus string
+
+// Event states of the Timetables API.
+const (
+	EventPlanned   EventStatus = "p"
+	EventAdded     EventStatus = "a"
+	EventCancelled EventStatus = "c"
+)
+
+// MessageType is the type of a TimetableMessage.
+type MessageType string
+
+// Message types of the Timetables API.
+const (
+	MessageHIM            MessageType = "h"
+	MessageQualityChange  MessageType = "q"
+	MessageFree           MessageType = "f"
+	MessageCauseOfDelay   MessageType = "d"
+	MessageIBIS           MessageType = "i"
+	MessageUnassignedIBIS MessageType = "u"
+	MessageDisruption     MessageType = "r"
+	MessageConnection     MessageType = "c"
+)
+
+// Timetable holds the stops of a station. Plans contain the planned data of one hour, change
+// documents only the attributes that changed.
+type Timetable struct {
+	Staleness
+
+	XMLName  xml.Name           `xml:"timetable"`
+	Station  string             `xml:"station,attr"`
+	EVA      int                `xml:"eva,attr"`
+	Stops    []TimetableStop    `xml:"s"`
+	Messages []TimetableMessage `xml:"m"`
+}
+
+// TimetableStop is a stop of a train at the station. The ID is stable across plan and change
+// documents.
+type TimetableStop struct {
+	ID        string             `xml:"id,attr"`
+	EVA       int                `xml:"eva,attr"`
+	TripLabel *TripLabel         `xml:"tl"`
+	Arrival   *TimetableEvent    `xml:"ar"`
+	Departure *TimetableEvent    `xml:"dp"`
+	Messages  []TimetableMessage `xml:"m"`
+}
+
+// TripLabel identifies the train of a stop, e.g. category "ICE" and number "1234".
+type TripLabel struct {
+	// Filter flags, e.g. "F" for long-distance trains.
+	Filter string `xml:"f,attr"`
+	// Type of the trip, e.g. "p" for a regular trip.
+	Type     string `xml:"t,attr"`
+	Owner    string `xml:"o,attr"`
+	Category string `xml:"c,attr"`
+	Number   string `xml:"n,attr"`
+}
+
+// TimetableEvent is an arrival or departure. Planned attributes are set in plans, changed
+// attributes in change documents.
+type TimetableEvent struct {
+	PlannedTime     TimetableTime `xml:"pt,attr"`
+	ChangedTime     TimetableTime `xml:"ct,attr"`
+	PlannedPlatform string        `xml:"pp,attr"`
+	ChangedPlatform string        `xml:"cp,attr"`
+	// PlannedPath lists the stations before an arrival or after a departure, separated by "|".
+	PlannedPath   string      `xml:"ppth,attr"`
+	ChangedPath   string      `xml:"cpth,attr"`
+	PlannedStatus EventStatus `xml:"ps,attr"`
+	ChangedStatus EventStatus `xml:"cs,attr"`
+	// Hidden is 1 if the event should not be shown, e.g. for passengers not allowed to board.
+	Hidden           int           `xml:"hi,attr"`
+	CancellationTime TimetableTime `xml:"clt,attr"`
+	// Line is the line indicator, e.g. "S8".
+	Line                   string             `xml:"l,attr"`
+	Wings                  string             `xml:"wings,attr"`
+	Transition             string             `xml:"tra,attr"`
+	PlannedDistantEndpoint string             `xml:"pde,attr"`
+	ChangedDistantEndpoint string             `xml:"cde,attr"`
+	Messages               []TimetableMessage `xml:"m"`
+}
+
+// Path returns the stations of PlannedPath.
+func (e *TimetableEvent) Path() []string {
+	return splitPath(e.PlannedPath)
+}
+
+// CurrentPath returns the stations of ChangedPath.
+func (e *TimetableEvent) CurrentPath() []string {
+	return splitPath(e.ChangedPath)
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "|")
+}
+
+// TimetableMessage is a message about a station, stop or event, e.g. the cause of a delay.
+type TimetableMessage struct {
+	ID   string        `xml:"id,attr"`
+	Type MessageType   `xml:"t,attr"`
+	From TimetableTime `xml:"from,attr"`
+	To   TimetableTime `xml:"to,attr"`
+	// Code identifies the text of delay and quality messages, see the API documentation.
+	Code             int           `xml:"c,attr"`
+	InternalText     string        `xml:"int,attr"`
+	ExternalText     string        `xml:"ext,attr"`
+	Category         string        `xml:"cat,attr"`
+	ExternalCategory string        `xml:"ec,attr"`
+	Timestamp        TimetableTime `xml:"ts,attr"`
+	Priority         string        `xml:"pr,attr"`
+	Owner            string        `xml:"o,attr"`
+	ExternalLink     string        `xml:"elnk,attr"`
+	// Deleted is 1 if the message has been revoked.
+	Deleted int `xml:"del,attr"`
+}
+
+// TimetablesAPI is a struct holding internal information about this API. Its methods can be used
+// to query the API.
+type TimetablesAPI struct {
+	client *Client
+}
+
+// Plan returns the planned stops at the station with the given eva number for one hour of a
+// day, e.g. Plan(8000105, date, 14) for trains between 14:00 and 14:59 in Europe/Berlin.
+func (t *TimetablesAPI) Plan(eva int, date time.Time, hour int) (*Timetable, error) {
+	return t.PlanContext(context.Background(), eva, date, hour)
+}
+
+// PlanContext is like Plan but aborts waiting for the rate limiter and the request once ctx
+// is done.
+func (t *TimetablesAPI) PlanContext(ctx context.Context, eva int, date time.Time, hour int) (*Timetable, error) {
+	day := date.In(berlin).Format("060102")
+	url := fmt.Sprintf("%s%s/plan/%d/%s/%02d", t.client.baseURL(), timetablesAPIPath, eva, day, hour)
+
+	timetable := &Timetable{}
+	err := t.get(ctx, &call{
+		operation: "Plan",
+		endpoint:  TimetablesPlanEndpoint,
+		url:       url,
+		attributes: []attribute.KeyValue{
+			attribute.Int("dbapi.eva", eva),
+			attribute.String("dbapi.timetables.date", day),
+			attribute.Int("dbapi.timetables.hour", hour),
+		},
+	}, timetable)
+	return timetable, err
+}
+
+// FullChanges returns all known changes of the stops at the station with the given eva number,
+// from now on until indefinitely into the future.
+func (t *TimetablesAPI) FullChanges(eva int) (*Timetable, error) {
+	return t.FullChangesContext(context.Background(), eva)
+}
+
+// FullChangesContext is like FullChanges but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (t *TimetablesAPI) FullChangesContext(ctx context.Context, eva int) (*Timetable, error) {
+	url := fmt.Sprintf("%s%s/fchg/%d", t.client.baseURL(), timetablesAPIPath, eva)
+
+	timetable := &Timetable{}
+	err := t.get(ctx, &call{
+		operation:  "FullChanges",
+		noCache:    true,
+		endpoint:   TimetablesFullChangesEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.eva", eva)},
+	}, timetable)
+	return timetable, err
+}
+
+// RecentChanges returns the changes of the stops at the station with the given eva number
+// that became known within the last two minutes.
+func (t *TimetablesAPI) RecentChanges(eva int) (*Timetable, error) {
+	return t.RecentChangesContext(context.Background(), eva)
+}
+
+// RecentChangesContext is like RecentChanges but aborts waiting for the rate limiter and the
+// request once ctx is done.
+func (t *TimetablesAPI) RecentChangesContext(ctx context.Context, eva int) (*Timetable, error) {
+	url := fmt.Sprintf("%s%s/rchg/%d", t.client.baseURL(), timetablesAPIPath, eva)
+
+	timetable := &Timetable{}
+	err := t.get(ctx, &call{
+		operation:  "RecentChanges",
+		noCache:    true,
+		endpoint:   TimetablesRecentChangesEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.eva", eva)},
+	}, timetable)
+	return timetable, err
+}
+
+func (t *TimetablesAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = timetablesAPIName
+	c.accept = "application/xml"
+	c.rateLimitPerMinute = t.client.apiConfig.TimetablesConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := t.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(timetablesAPIName, resp, data, xml.Unmarshal)
+}
@@ -0,0 +1,151 @@
+package dbapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/timetables/v1/", ".xml", "")
+}
+
+func TestTimetablesAPI_Plan(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var accept string
+	c := New("SomeFakeToken", Config{
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				accept = req.Header.Get("Accept")
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+	tt := c.TimetablesAPI()
+
+	timetable, err := tt.Plan(8000105, time.Date(2022, 7, 14, 0, 0, 0, 0, berlin), 12)
+	assert.Nil(err)
+	assert.Equal("application/xml", accept)
+
+	assert.Equal("Frankfurt(Main)Hbf", timetable.Station)
+	assert.Len(timetable.Stops, 5)
+
+	stop := timetable.Stops[0]
+	assert.Equal("-5296516961807204721-2207141152-5", stop.ID)
+	assert.Equal(&TripLabel{Filter: "F", Type: "p", Owner: "80", Category: "ICE", Number: "1234"}, stop.TripLabel)
+	assert.Equal(time.Date(2022, 7, 14, 11, 58, 0, 0, berlin), stop.Arrival.PlannedTime.Time)
+	assert.Equal("7", stop.Arrival.PlannedPlatform)
+	assert.Equal([]string{"Berlin Hbf", "Kassel-Wilhelmshöhe", "Fulda"}, stop.Arrival.Path())
+	assert.Equal([]string{"Mannheim Hbf", "Karlsruhe Hbf", "Basel SBB"}, stop.Departure.Path())
+	assert.True(stop.Departure.ChangedTime.IsZero())
+
+	assert.Equal("8", timetable.Stops[1].Departure.Line)
+	assert.Nil(timetable.Stops[2].Arrival)
+	assert.Nil(timetable.Stops[3].Departure)
+
+	_, err = tt.Plan(8000105, time.Date(2022, 7, 14, 0, 0, 0, 0, berlin), 13)
+	assert.Equal(&APIError{API: "timetables", StatusCode: 404}, err)
+	assert.EqualError(err, "timetables: error 404: Not Found")
+}
+
+func TestTimetablesAPI_Changes(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	tt := c.TimetablesAPI()
+
+	timetable, err := tt.FullChanges(8000105)
+	assert.Nil(err)
+	assert.Equal(8000105, timetable.EVA)
+	assert.Len(timetable.Stops, 5)
+
+	assert.Equal(MessageHIM, timetable.Messages[0].Type)
+	assert.Equal("Aufzug zu Gleis 7 außer Betrieb", timetable.Messages[0].ExternalText)
+	assert.Equal(time.Date(2022, 7, 14, 22, 0, 0, 0, berlin), timetable.Messages[0].To.Time)
+
+	delayed := timetable.Stops[0]
+	assert.Nil(delayed.TripLabel)
+	assert.Equal(time.Date(2022, 7, 14, 12, 11, 0, 0, berlin), delayed.Departure.ChangedTime.Time)
+	assert.Equal("8", delayed.Departure.ChangedPlatform)
+	assert.Equal(MessageCauseOfDelay, delayed.Departure.Messages[0].Type)
+	assert.Equal(43, delayed.Departure.Messages[0].Code)
+
+	cancelled := timetable.Stops[1]
+	assert.Equal(EventCancelled, cancelled.Departure.ChangedStatus)
+	assert.Equal(time.Date(2022, 7, 14, 11, 0, 0, 0, berlin), cancelled.Departure.CancellationTime.Time)
+
+	added := timetable.Stops[3]
+	assert.Equal(EventAdded, added.Departure.PlannedStatus)
+	assert.Equal("e", added.TripLabel.Type)
+
+	timetable, err = tt.RecentChanges(8000105)
+	assert.Nil(err)
+	assert.Len(timetable.Stops, 1)
+	assert.Equal(80, timetable.Stops[0].Departure.Messages[0].Code)
+
+	_, err = tt.RecentChanges(1)
+	assert.IsType(&APIError{}, err)
+}
+
+func TestTimetablesAPI_ChangesNotCached(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	var requests int
+	c := New("SomeFakeToken", Config{
+		CacheConfig: CacheConfig{Backend: NewMemoryCache(10), DefaultTTL: time.Hour},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+	tt := c.TimetablesAPI()
+
+	for i := 0; i < 2; i++ {
+		_, err := tt.RecentChanges(8000105)
+		assert.Nil(err)
+		_, err = tt.FullChanges(8000105)
+		assert.Nil(err)
+		_, err = tt.Plan(8000105, time.Date(2022, 7, 14, 0, 0, 0, 0, berlin), 12)
+		assert.Nil(err)
+	}
+	// Only the plan is served from the cache
+	assert.Equal(5, requests)
+}
+
+func TestTimetablesAPI_RateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{
+		TimetablesConfig: TimetablesConfig{RateLimitPerMinute: 60}, // Should sleep for ~1 second
+	})
+	tt := c.TimetablesAPI()
+
+	_, err := tt.RecentChanges(8000105)
+	assert.Nil(err)
+	start := time.Now()
+	_, err = tt.RecentChanges(8000105)
+	assert.Nil(err)
+
+	assert.True(time.Since(start) >= 900*time.Millisecond, "Duration must be >= 1 second, was "+time.Since(start).String())
+}
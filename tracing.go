@@ -0,0 +1,108 @@
+package dbapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/amuttsch/go-db-api"
+
+func newTracer(tracerProvider trace.TracerProvider) trace.Tracer {
+	if tracerProvider == nil {
+		tracerProvider = noop.NewTracerProvider()
+	}
+	return tracerProvider.Tracer(tracerName)
+}
+
+// startCallSpan starts the span covering a logical call, including cache lookups, waiting
+// for a shared request and all HTTP attempts.
+func (client *Client) startCallSpan(ctx context.Context, c *call) (context.Context, trace.Span) {
+	attributes := append([]attribute.KeyValue{
+		attribute.String("dbapi.api", c.api),
+		attribute.String("dbapi.endpoint", c.endpoint),
+	}, c.attributes...)
+
+	return client.tracer.Start(ctx, "dbapi."+c.api+"."+c.operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attributes...))
+}
+
+// endCallSpan records the outcome of a logical call. dbapi.attempts is zero if the call was
+// served from the cache or by a concurrent identical call.
+func endCallSpan(span trace.Span, c *call, resp *response, err error) {
+	attempts := int(atomic.LoadInt32(&c.attempts))
+	retries := 0
+	if attempts > 1 {
+		retries = attempts - 1
+	}
+	span.SetAttributes(
+		attribute.Int("dbapi.attempts", attempts),
+		attribute.Int("dbapi.retries", retries),
+	)
+
+	setSpanOutcome(span, resp, err)
+	span.End()
+}
+
+// startAttemptSpan starts the span of a single HTTP request, including the time spent
+// waiting for the rate limiter.
+func (client *Client) startAttemptSpan(ctx context.Context, req *http.Request, info RequestInfo) (context.Context, trace.Span) {
+	return client.tracer.Start(ctx, "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+			attribute.String("dbapi.endpoint", info.Endpoint),
+			attribute.Int("dbapi.attempt", info.Attempt),
+		))
+}
+
+func endAttemptSpan(span trace.Span, resp *response, err error) {
+	setSpanOutcome(span, resp, err)
+	span.End()
+}
+
+func setSpanOutcome(span trace.Span, resp *response, err error) {
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.statusCode))
+		if resp.statusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(resp.statusCode))
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// injectTraceContext propagates the span in ctx to the API using the global propagator.
+func (client *Client) injectTraceContext(ctx context.Context, req *http.Request) {
+	if client.apiConfig.TracerProvider != nil {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+}
+
+// filterAttributes turns query parameters of a filter request into span attributes.
+func filterAttributes(q url.Values) []attribute.KeyValue {
+	keys := make([]string, 0, len(q))
+	for key := range q {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, attribute.String("dbapi.filter."+key, q.Get(key)))
+	}
+	return attributes
+}
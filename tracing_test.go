@@ -0,0 +1,62 @@
+package dbapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	c := New("SomeFakeToken", Config{
+		StationDataConfig: StationDataConfig{RateLimitPerMinute: 6000},
+		TracerProvider:    tracerProvider,
+	})
+	s := c.StationDataAPI()
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+	s.StationByIDContext(ctx, 1)
+	s.StationByFilterContext(ctx, StationDataStationRequest{Federalstate: "hessen"})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(spans, 5)
+
+	attempt, call := spans[0], spans[1]
+	assert.Equal("HTTP GET", attempt.Name)
+	assert.Equal("dbapi.stationdata.StationByID", call.Name)
+	assert.Equal(call.SpanContext.SpanID(), attempt.Parent.SpanID())
+	assert.Equal(parent.SpanContext().SpanID(), call.Parent.SpanID())
+
+	assert.Contains(attempt.Attributes, attribute.Int("http.response.status_code", 200))
+	assert.Contains(attempt.Attributes, attribute.Int("dbapi.attempt", 1))
+	assert.Contains(attempt.Attributes, attribute.String("dbapi.endpoint", StationDataStationByIDEndpoint))
+	assertHasAttribute(t, attempt.Attributes, "dbapi.rate_limit.wait_seconds")
+	assert.Contains(call.Attributes, attribute.Int("dbapi.station.id", 1))
+	assert.Contains(call.Attributes, attribute.Int("dbapi.retries", 0))
+
+	filterCall := spans[3]
+	assert.Equal("dbapi.stationdata.StationByFilter", filterCall.Name)
+	assert.Contains(filterCall.Attributes, attribute.String("dbapi.filter.federalstate", "hessen"))
+}
+
+func assertHasAttribute(t *testing.T, attributes []attribute.KeyValue, key attribute.Key) {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return
+		}
+	}
+	t.Errorf("attribute %s not found in %v", key, attributes)
+}
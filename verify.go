@@ -0,0 +1,241 @@
+package dbapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VerifyStatus classifies the result of probing an API with a key.
+type VerifyStatus int
+
+const (
+	// VerifyValid means the key is valid and subscribed to the API.
+	VerifyValid VerifyStatus = iota
+	// VerifyInvalidToken means the API rejected the credentials (401).
+	VerifyInvalidToken
+	// VerifyNotSubscribed means the credentials are valid but not subscribed to the API (403).
+	VerifyNotSubscribed
+	// VerifyRateLimited means the quota of the key is currently exhausted (429).
+	VerifyRateLimited
+	// VerifyFailed means the API could not be probed, e.g. because of a network error.
+	VerifyFailed
+)
+
+func (s VerifyStatus) String() string {
+	switch s {
+	case VerifyValid:
+		return "valid"
+	case VerifyInvalidToken:
+		return "invalid token"
+	case VerifyNotSubscribed:
+		return "not subscribed"
+	case VerifyRateLimited:
+		return "rate limited"
+	case VerifyFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// VerifyResult is the outcome of probing one API with one key of the key pool.
+type VerifyResult struct {
+	API string
+	// Key identifies the key as in KeyUsage.
+	Key    string
+	Status VerifyStatus
+	// StatusCode is the HTTP status of the probe, zero if no response was received.
+	StatusCode int
+	Err        error
+}
+
+// VerifyReport holds the results of Client.Verify.
+type VerifyReport struct {
+	Results []VerifyResult
+}
+
+// OK reports whether every key can be used with every API. Rate limited keys count as usable.
+func (r *VerifyReport) OK() bool {
+	return r.Err() == nil
+}
+
+// Err returns an error describing all probes that failed, or nil.
+func (r *VerifyReport) Err() error {
+	var failures []string
+	for _, result := range r.Results {
+		if result.Status == VerifyValid || result.Status == VerifyRateLimited {
+			continue
+		}
+		failure := fmt.Sprintf("%s with %s: %s", result.API, result.Key, result.Status)
+		if result.Err != nil {
+			failure += " (" + result.Err.Error() + ")"
+		}
+		failures = append(failures, failure)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return errors.New("dbapi: verification failed: " + strings.Join(failures, "; "))
+}
+
+// Names of the APIs as passed to Client.Verify and reported in VerifyResult and RequestInfo.
+const (
+	APIStationData     = stationDataAPIName
+	APITimetables      = timetablesAPIName
+	APIFahrplan        = fahrplanAPIName
+	APIFaSta           = fastaAPIName
+	APIParking         = parkingAPIName
+	APIBetriebsstellen = betriebsstellenAPIName
+	APIPhotos          = photosAPIName
+	APIReisezentren    = reisezentrenAPIName
+	APIWagenreihung    = wagenreihungAPIName
+)
+
+// Verify sends a cheap authenticated request to each of the given APIs with every key of the
+// key pool and classifies the responses, so misconfigurations can be detected at startup. Pass
+// the APIs your keys are subscribed to, e.g. APIStationData and APITimetables; without any,
+// only the StationData API is probed. The probes bypass the cache but are subject to rate
+// limiting, so each of them counts against the quota.
+func (client *Client) Verify(ctx context.Context, apis ...string) *VerifyReport {
+	report := &VerifyReport{}
+	if len(apis) == 0 {
+		apis = []string{APIStationData}
+	}
+
+	var probes []*call
+	all := client.probes()
+	for _, api := range apis {
+		probe, ok := all[api]
+		if !ok {
+			report.Results = append(report.Results, VerifyResult{
+				API:    api,
+				Status: VerifyFailed,
+				Err:    fmt.Errorf("unknown API %q", api),
+			})
+			continue
+		}
+		probes = append(probes, probe)
+	}
+
+	if len(client.keys.keys) == 0 {
+		for _, probe := range probes {
+			report.Results = append(report.Results, VerifyResult{
+				API:    probe.api,
+				Status: VerifyInvalidToken,
+				Err:    errors.New("no API token given"),
+			})
+		}
+		return report
+	}
+
+	for _, probe := range probes {
+		for _, key := range client.keys.keys {
+			c := *probe
+			resp, err := client.attempt(ctx, &c, nil, key)
+			client.keys.record(key, resp)
+			report.Results = append(report.Results, classifyProbe(probe.api, key, resp, err))
+		}
+	}
+	return report
+}
+
+// probes returns a cheap request for every API the Client implements by API name.
+func (client *Client) probes() map[string]*call {
+	return map[string]*call{
+		stationDataAPIName: {
+			api:                stationDataAPIName,
+			operation:          "Verify",
+			endpoint:           StationDataSZentraleByIDEndpoint,
+			url:                fmt.Sprintf("%s%s/szentralen/%d", client.baseURL(), stadaAPIPath, 1),
+			rateLimitPerMinute: client.apiConfig.StationDataConfig.RateLimitPerMinute,
+		},
+		timetablesAPIName: {
+			api:                timetablesAPIName,
+			operation:          "Verify",
+			endpoint:           TimetablesRecentChangesEndpoint,
+			url:                fmt.Sprintf("%s%s/rchg/%d", client.baseURL(), timetablesAPIPath, 8000105),
+			accept:             "application/xml",
+			rateLimitPerMinute: client.apiConfig.TimetablesConfig.RateLimitPerMinute,
+		},
+		fahrplanAPIName: {
+			api:                fahrplanAPIName,
+			operation:          "Verify",
+			endpoint:           FahrplanLocationEndpoint,
+			url:                fmt.Sprintf("%s%s/location/%s", client.baseURL(), fahrplanAPIPath, "Frankfurt"),
+			rateLimitPerMinute: client.apiConfig.FahrplanConfig.RateLimitPerMinute,
+		},
+		fastaAPIName: {
+			api:                fastaAPIName,
+			operation:          "Verify",
+			endpoint:           FaStaStationEndpoint,
+			url:                fmt.Sprintf("%s%s/stations/%d", client.baseURL(), fastaAPIPath, 1),
+			rateLimitPerMinute: client.apiConfig.FaStaConfig.RateLimitPerMinute,
+		},
+		parkingAPIName: {
+			api:                parkingAPIName,
+			operation:          "Verify",
+			endpoint:           ParkingSpaceEndpoint,
+			url:                fmt.Sprintf("%s%s/spaces/%d", client.baseURL(), parkingAPIPath, 1),
+			rateLimitPerMinute: client.apiConfig.ParkingConfig.RateLimitPerMinute,
+		},
+		betriebsstellenAPIName: {
+			api:                betriebsstellenAPIName,
+			operation:          "Verify",
+			endpoint:           BetriebsstelleEndpoint,
+			url:                fmt.Sprintf("%s%s/betriebsstellen/%s", client.baseURL(), betriebsstellenAPIPath, "FF"),
+			rateLimitPerMinute: client.apiConfig.BetriebsstellenConfig.RateLimitPerMinute,
+		},
+		photosAPIName: {
+			api:                photosAPIName,
+			operation:          "Verify",
+			endpoint:           PhotosStationEndpoint,
+			url:                fmt.Sprintf("%s%s/%s/stations/%d", client.baseURL(), photosAPIPath, photosGermany, 8000105),
+			rateLimitPerMinute: client.apiConfig.PhotosConfig.RateLimitPerMinute,
+		},
+		reisezentrenAPIName: {
+			api:                reisezentrenAPIName,
+			operation:          "Verify",
+			endpoint:           ReisezentrumEndpoint,
+			url:                fmt.Sprintf("%s%s/reisezentren/%d", client.baseURL(), reisezentrenAPIPath, 1),
+			rateLimitPerMinute: client.apiConfig.ReisezentrenConfig.RateLimitPerMinute,
+		},
+		wagenreihungAPIName: {
+			api:                wagenreihungAPIName,
+			operation:          "Verify",
+			endpoint:           WagenreihungFormationEndpoint,
+			url:                fmt.Sprintf("%s%s/%d/%s", client.baseURL(), wagenreihungAPIPath, 1000, time.Now().In(berlin).Format(wagenreihungRequestLayout)),
+			rateLimitPerMinute: client.apiConfig.WagenreihungConfig.RateLimitPerMinute,
+		},
+	}
+}
+
+func classifyProbe(api string, key *apiKey, resp *response, err error) VerifyResult {
+	key.mu.Lock()
+	result := VerifyResult{API: api, Key: key.usage.Key, Err: err}
+	key.mu.Unlock()
+
+	if err != nil {
+		result.Status = VerifyFailed
+		return result
+	}
+
+	result.StatusCode = resp.statusCode
+	switch {
+	case resp.statusCode < 300, resp.statusCode == http.StatusNotFound:
+		result.Status = VerifyValid
+	case resp.statusCode == http.StatusUnauthorized:
+		result.Status = VerifyInvalidToken
+	case resp.statusCode == http.StatusForbidden:
+		result.Status = VerifyNotSubscribed
+	case resp.statusCode == http.StatusTooManyRequests:
+		result.Status = VerifyRateLimited
+	default:
+		result.Status = VerifyFailed
+		result.Err = errors.New(http.StatusText(resp.statusCode))
+	}
+	return result
+}
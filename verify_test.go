@@ -0,0 +1,74 @@
+package dbapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Verify(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	statusByToken := map[string]int{
+		"Bearer BadToken":     http.StatusUnauthorized,
+		"Bearer Unsubscribed": http.StatusForbidden,
+		"Bearer Exhausted":    http.StatusTooManyRequests,
+	}
+	c := New("GoodToken", Config{
+		APIKeys: []APIKey{{Token: "BadToken"}, {Token: "Unsubscribed"}, {Token: "Exhausted"}},
+		Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if status, ok := statusByToken[req.Header.Get("Authorization")]; ok {
+					return &http.Response{
+						StatusCode: status,
+						Body:       io.NopCloser(strings.NewReader(http.StatusText(status))),
+						Request:    req,
+					}, nil
+				}
+				return next.RoundTrip(req)
+			})
+		}},
+	})
+
+	apis := []string{APIStationData, APITimetables, APIFahrplan, APIFaSta, APIParking,
+		APIBetriebsstellen, APIPhotos, APIReisezentren, APIWagenreihung}
+	report := c.Verify(context.Background(), apis...)
+
+	expected := []VerifyStatus{VerifyValid, VerifyInvalidToken, VerifyNotSubscribed, VerifyRateLimited}
+	assert.Len(report.Results, len(expected)*len(apis))
+	for i, result := range report.Results {
+		assert.Equal(apis[i/len(expected)], result.API)
+		assert.Equal(expected[i%len(expected)], result.Status)
+	}
+	assert.Equal("stationdata", report.Results[0].API)
+	assert.Equal(404, report.Results[0].StatusCode)
+	assert.False(report.OK())
+	assert.ErrorContains(report.Err(), "dbapi: verification failed: stationdata with ...oken: invalid token; stationdata with ...ibed: not subscribed")
+	assert.ErrorContains(report.Err(), "timetables with ...oken: invalid token")
+
+	report = c.Verify(context.Background())
+	assert.Len(report.Results, len(expected))
+	for _, result := range report.Results {
+		assert.Equal(APIStationData, result.API)
+	}
+
+	report = c.Verify(context.Background(), "unknown")
+	assert.Len(report.Results, 1)
+	assert.Equal(VerifyFailed, report.Results[0].Status)
+	assert.EqualError(report.Results[0].Err, `unknown API "unknown"`)
+
+	report = New("GoodToken", Config{}).Verify(context.Background())
+	assert.True(report.OK())
+
+	report = New("", Config{}).Verify(context.Background())
+	assert.Equal(VerifyInvalidToken, report.Results[0].Status)
+	assert.False(report.OK())
+}
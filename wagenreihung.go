@@ -0,0 +1,381 @@
+package dbapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const wagenreihungAPIPath = "/wagenreihung/v1"
+
+// wagenreihungAPIName identifies the Wagenreihung API in instrumentation events.
+const wagenreihungAPIName = "wagenreihung"
+
+const (
+	// wagenreihungRequestLayout is the layout of the departure time in the request path.
+	wagenreihungRequestLayout = "200601021504"
+	// wagenreihungTimeLayout is the layout of times in responses, which are in Europe/Berlin.
+	wagenreihungTimeLayout = "2006-01-02T15:04:05"
+)
+
+// WagenreihungFormationEndpoint is the endpoint template of the Wagenreihung API.
+const WagenreihungFormationEndpoint = wagenreihungAPIPath + "/{trainnumber}/{departure}"
+
+// WagenreihungConfig provides configuration options for the Wagenreihung API. Set
+// RateLimitPerMinute to zero if you want to disable rate limiting done in the library.
+type WagenreihungConfig struct {
+	RateLimitPerMinute int
+}
+
+// CoachClass is the travel class offered by a Coach.
+type CoachClass int
+
+// Travel classes of coaches.
+const (
+	// CoachNoClass is used for coaches without seats, e.g. locomotives and dining cars.
+	CoachNoClass CoachClass = iota
+	CoachFirstClass
+	CoachSecondClass
+	CoachFirstAndSecondClass
+)
+
+// CoachFacilityType is the type of a CoachFacility.
+type CoachFacilityType string
+
+// Common facility types of coaches. The API knows further types, e.g. for air conditioning.
+const (
+	CoachFacilityBike       CoachFacilityType = "PLAETZEFAHRRAD"
+	CoachFacilityWheelchair CoachFacilityType = "PLAETZEROLLSTUHL"
+	CoachFacilityQuietZone  CoachFacilityType = "RUHEBEREICH"
+	CoachFacilityFamilyZone CoachFacilityType = "FAMILIEZONE"
+	CoachFacilityToddlers   CoachFacilityType = "KLEINKINDABTEIL"
+	CoachFacilityInfo       CoachFacilityType = "INFO"
+)
+
+// TrainFormation is the coach sequence of a train at a stop.
+type TrainFormation struct {
+	Category string
+	Number   string
+	// Planned is set if the formation is taken from the plan rather than from the actual train.
+	Planned bool
+
+	StationName string
+	EVA         int
+	RIL100      string
+	Track       string
+	// ArrivalTime is zero at the first stop of the train, DepartureTime at the last one.
+	ArrivalTime   time.Time
+	DepartureTime time.Time
+
+	// Sectors are the sectors of the platform in the order of their position.
+	Sectors []PlatformSector
+	// Groups are the parts of the train, e.g. both units of a double ICE, which may run to
+	// different destinations.
+	Groups []CoachGroup
+}
+
+// Coaches returns the coaches of all groups in the order of their position on the platform.
+func (f *TrainFormation) Coaches() []Coach {
+	var coaches []Coach
+	for _, group := range f.Groups {
+		coaches = append(coaches, group.Coaches...)
+	}
+	return coaches
+}
+
+// PlatformSector is a section of the platform, e.g. "A".
+type PlatformSector struct {
+	Name     string
+	Position PlatformPosition
+}
+
+// PlatformPosition is the extent of a sector or coach along the platform, measured from its
+// start in meters and in percent of its length.
+type PlatformPosition struct {
+	StartMeter   float64
+	EndMeter     float64
+	StartPercent float64
+	EndPercent   float64
+}
+
+// CoachGroup is a unit of coaches running together, e.g. an ICE train set.
+type CoachGroup struct {
+	Name        string
+	TrainNumber string
+	Origin      string
+	Destination string
+	Coaches     []Coach
+}
+
+// Coach is a vehicle of a train.
+type Coach struct {
+	// Number is the number displayed on the coach, empty for vehicles without seats.
+	Number        string
+	VehicleNumber string
+	// Type is the type designation, e.g. "Apmz".
+	Type string
+	// Category is the vehicle category as sent by the API, e.g. "REISEZUGWAGENERSTEKLASSE" or
+	// "TRIEBKOPF".
+	Category string
+	Class    CoachClass
+	Sector   string
+	Position PlatformPosition
+	// Closed is set if passengers cannot board the coach.
+	Closed     bool
+	Facilities []CoachFacility
+}
+
+// HasFacility reports whether the coach has an available facility of the given type.
+func (c *Coach) HasFacility(facilityType CoachFacilityType) bool {
+	for _, facility := range c.Facilities {
+		if facility.Type == facilityType && facility.Available {
+			return true
+		}
+	}
+	return false
+}
+
+// CoachFacility is a facility of a coach, e.g. bike spaces.
+type CoachFacility struct {
+	Type CoachFacilityType
+	// Count is the number of places, zero if not applicable.
+	Count     int
+	Available bool
+}
+
+type rawWagenreihung struct {
+	Data struct {
+		Formation rawFormation `json:"istformation"`
+	} `json:"data"`
+}
+
+type rawFormation struct {
+	Category string            `json:"zuggattung"`
+	Number   string            `json:"zugnummer"`
+	Planned  bool              `json:"istplaninformation"`
+	Groups   []rawVehicleGroup `json:"allFahrzeuggruppe"`
+	Stop     struct {
+		StationName   string      `json:"bahnhofsname"`
+		EVA           string      `json:"evanummer"`
+		RIL100        string      `json:"rl100"`
+		Track         string      `json:"gleisbezeichnung"`
+		ArrivalTime   string      `json:"ankunftszeit"`
+		DepartureTime string      `json:"abfahrtszeit"`
+		Sectors       []rawSector `json:"allSektor"`
+	} `json:"halt"`
+}
+
+type rawSector struct {
+	Name     string      `json:"sektorbezeichnung"`
+	Position rawPosition `json:"positionamgleis"`
+}
+
+type rawPosition struct {
+	StartMeter   string `json:"startmeter"`
+	EndMeter     string `json:"endemeter"`
+	StartPercent string `json:"startprozent"`
+	EndPercent   string `json:"endeprozent"`
+}
+
+type rawVehicleGroup struct {
+	Name        string       `json:"fahrzeuggruppebezeichnung"`
+	TrainNumber string       `json:"verkehrlichezugnummer"`
+	Origin      string       `json:"startbetriebsstellename"`
+	Destination string       `json:"zielbetriebsstellename"`
+	Vehicles    []rawVehicle `json:"allFahrzeug"`
+}
+
+type rawVehicle struct {
+	Number        string      `json:"wagenordnungsnummer"`
+	VehicleNumber string      `json:"fahrzeugnummer"`
+	Type          string      `json:"fahrzeugtyp"`
+	Category      string      `json:"kategorie"`
+	Sector        string      `json:"fahrzeugsektor"`
+	Status        string      `json:"status"`
+	Position      rawPosition `json:"positionamhalt"`
+	Facilities    []struct {
+		Type   string `json:"ausstattungsart"`
+		Count  string `json:"anzahl"`
+		Status string `json:"status"`
+	} `json:"allFahrzeugausstattung"`
+}
+
+// WagenreihungAPI is a struct holding internal information about this API. Its methods can be
+// used to query the API.
+type WagenreihungAPI struct {
+	client *Client
+}
+
+// Formation returns the coach sequence of the train with the given number at the stop it
+// departs from at the scheduled departure time.
+func (w *WagenreihungAPI) Formation(trainNumber int, departure time.Time) (*TrainFormation, error) {
+	return w.FormationContext(context.Background(), trainNumber, departure)
+}
+
+// FormationContext is like Formation but aborts waiting for the rate limiter and the request
+// once ctx is done.
+func (w *WagenreihungAPI) FormationContext(ctx context.Context, trainNumber int, departure time.Time) (*TrainFormation, error) {
+	url := fmt.Sprintf("%s%s/%d/%s", w.client.baseURL(), wagenreihungAPIPath, trainNumber,
+		departure.In(berlin).Format(wagenreihungRequestLayout))
+
+	raw := &rawWagenreihung{}
+	err := w.get(ctx, &call{
+		operation:  "Formation",
+		endpoint:   WagenreihungFormationEndpoint,
+		url:        url,
+		attributes: []attribute.KeyValue{attribute.Int("dbapi.wagenreihung.trainnumber", trainNumber)},
+	}, raw)
+	if err != nil {
+		return nil, err
+	}
+	return newTrainFormation(raw.Data.Formation)
+}
+
+func (w *WagenreihungAPI) get(ctx context.Context, c *call, data interface{}) error {
+	c.api = wagenreihungAPIName
+	c.rateLimitPerMinute = w.client.apiConfig.WagenreihungConfig.RateLimitPerMinute
+	c.noStale = !canMarkStale(data)
+
+	resp, err := w.client.get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(wagenreihungAPIName, resp, data, json.Unmarshal)
+}
+
+func newTrainFormation(raw rawFormation) (*TrainFormation, error) {
+	formation := &TrainFormation{
+		Category:    raw.Category,
+		Number:      raw.Number,
+		Planned:     raw.Planned,
+		StationName: raw.Stop.StationName,
+		RIL100:      raw.Stop.RIL100,
+		Track:       raw.Stop.Track,
+	}
+
+	var err error
+	if raw.Stop.EVA != "" {
+		if formation.EVA, err = strconv.Atoi(raw.Stop.EVA); err != nil {
+			return nil, err
+		}
+	}
+	if formation.ArrivalTime, err = parseWagenreihungTime(raw.Stop.ArrivalTime); err != nil {
+		return nil, err
+	}
+	if formation.DepartureTime, err = parseWagenreihungTime(raw.Stop.DepartureTime); err != nil {
+		return nil, err
+	}
+
+	for _, sector := range raw.Stop.Sectors {
+		position, err := newPlatformPosition(sector.Position)
+		if err != nil {
+			return nil, err
+		}
+		formation.Sectors = append(formation.Sectors, PlatformSector{Name: sector.Name, Position: position})
+	}
+
+	for _, rawGroup := range raw.Groups {
+		group := CoachGroup{
+			Name:        rawGroup.Name,
+			TrainNumber: rawGroup.TrainNumber,
+			Origin:      rawGroup.Origin,
+			Destination: rawGroup.Destination,
+		}
+		for _, vehicle := range rawGroup.Vehicles {
+			coach, err := newCoach(vehicle)
+			if err != nil {
+				return nil, err
+			}
+			group.Coaches = append(group.Coaches, coach)
+		}
+		formation.Groups = append(formation.Groups, group)
+	}
+	return formation, nil
+}
+
+func newCoach(raw rawVehicle) (Coach, error) {
+	position, err := newPlatformPosition(raw.Position)
+	if err != nil {
+		return Coach{}, err
+	}
+
+	coach := Coach{
+		Number:        raw.Number,
+		VehicleNumber: raw.VehicleNumber,
+		Type:          raw.Type,
+		Category:      raw.Category,
+		Class:         coachClass(raw.Category),
+		Sector:        raw.Sector,
+		Position:      position,
+		Closed:        raw.Status == "GESCHLOSSEN",
+	}
+	for _, facility := range raw.Facilities {
+		count, err := parseWagenreihungNumber(facility.Count)
+		if err != nil {
+			return Coach{}, err
+		}
+		coach.Facilities = append(coach.Facilities, CoachFacility{
+			Type:      CoachFacilityType(facility.Type),
+			Count:     int(count),
+			Available: facility.Status == "VERFUEGBAR",
+		})
+	}
+	return coach, nil
+}
+
+// coachClass derives the travel class from a vehicle category, e.g.
+// "DOPPELSTOCKWAGENERSTEZWEITEKLASSE".
+func coachClass(category string) CoachClass {
+	switch {
+	case strings.Contains(category, "ERSTEZWEITEKLASSE"):
+		return CoachFirstAndSecondClass
+	case strings.Contains(category, "ERSTEKLASSE"):
+		return CoachFirstClass
+	case strings.Contains(category, "ZWEITEKLASSE"):
+		return CoachSecondClass
+	default:
+		return CoachNoClass
+	}
+}
+
+func newPlatformPosition(raw rawPosition) (PlatformPosition, error) {
+	var position PlatformPosition
+	values := []struct {
+		dst *float64
+		src string
+	}{
+		{&position.StartMeter, raw.StartMeter},
+		{&position.EndMeter, raw.EndMeter},
+		{&position.StartPercent, raw.StartPercent},
+		{&position.EndPercent, raw.EndPercent},
+	}
+	for _, value := range values {
+		parsed, err := parseWagenreihungNumber(value.src)
+		if err != nil {
+			return PlatformPosition{}, err
+		}
+		*value.dst = parsed
+	}
+	return position, nil
+}
+
+// parseWagenreihungNumber parses the numbers of the API, which are sent as strings. Empty
+// strings are zero.
+func parseWagenreihungNumber(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseWagenreihungTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(wagenreihungTimeLayout, value, berlin)
+}
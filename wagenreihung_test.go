@@ -0,0 +1,97 @@
+package dbapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	serveTestdata("/wagenreihung/v1/", ".json", `{"code":404,"message":"Not Found"}`)
+}
+
+func TestWagenreihungAPI_Formation(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	w := c.WagenreihungAPI()
+
+	// The departure is given in UTC and requested in Europe/Berlin
+	formation, err := w.Formation(1000, time.Date(2019, 7, 4, 10, 8, 0, 0, time.UTC))
+	assert.Nil(err)
+
+	assert.Equal("ICE", formation.Category)
+	assert.Equal("1000", formation.Number)
+	assert.False(formation.Planned)
+	assert.Equal(8000105, formation.EVA)
+	assert.Equal("FF", formation.RIL100)
+	assert.Equal("7", formation.Track)
+	assert.Equal(time.Date(2019, 7, 4, 12, 2, 0, 0, berlin), formation.ArrivalTime)
+	assert.Equal(time.Date(2019, 7, 4, 12, 8, 0, 0, berlin), formation.DepartureTime)
+
+	assert.Equal([]PlatformSector{
+		{Name: "A", Position: PlatformPosition{StartMeter: 0, EndMeter: 62, StartPercent: 0, EndPercent: 15}},
+		{Name: "B", Position: PlatformPosition{StartMeter: 62, EndMeter: 124.5, StartPercent: 15, EndPercent: 30}},
+	}, formation.Sectors)
+
+	assert.Len(formation.Groups, 1)
+	group := formation.Groups[0]
+	assert.Equal("ICE0304", group.Name)
+	assert.Equal("München Hbf", group.Origin)
+	assert.Equal("Berlin Hbf", group.Destination)
+
+	coaches := formation.Coaches()
+	assert.Len(coaches, 4)
+
+	assert.Equal(CoachNoClass, coaches[0].Class)
+	assert.Equal("", coaches[0].Number)
+	assert.Empty(coaches[0].Facilities)
+
+	assert.Equal(Coach{
+		Number:        "11",
+		VehicleNumber: "938054010443",
+		Type:          "Apmz",
+		Category:      "REISEZUGWAGENERSTEKLASSE",
+		Class:         CoachFirstClass,
+		Sector:        "A",
+		Position:      PlatformPosition{StartMeter: 23, EndMeter: 49.4, StartPercent: 6, EndPercent: 12},
+		Facilities: []CoachFacility{
+			{Type: CoachFacilityQuietZone, Available: true},
+			{Type: CoachFacilityWheelchair, Count: 2, Available: true},
+		},
+	}, coaches[1])
+	assert.True(coaches[1].HasFacility(CoachFacilityQuietZone))
+	assert.True(coaches[1].HasFacility(CoachFacilityWheelchair))
+	assert.False(coaches[1].HasFacility(CoachFacilityBike))
+
+	assert.Equal(CoachSecondClass, coaches[2].Class)
+	assert.True(coaches[2].Closed)
+	assert.False(coaches[2].HasFacility(CoachFacilityBike))
+	assert.True(coaches[2].HasFacility(CoachFacilityFamilyZone))
+
+	assert.Equal(CoachFirstAndSecondClass, coaches[3].Class)
+	assert.True(coaches[3].HasFacility(CoachFacilityBike))
+	assert.Equal(4, coaches[3].Facilities[0].Count)
+}
+
+func TestWagenreihungAPI_FormationErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	once.Do(startMockServer)
+
+	APIURL = "http://" + serverAddr
+
+	c := New("SomeFakeToken", Config{})
+	w := c.WagenreihungAPI()
+
+	_, err := w.Formation(1000, time.Date(2019, 7, 4, 12, 9, 0, 0, berlin))
+	assert.Error(err)
+
+	_, err = w.Formation(1001, time.Date(2019, 7, 4, 12, 8, 0, 0, berlin))
+	assert.Equal(&APIError{API: "wagenreihung", StatusCode: 404, Message: `{"code":404,"message":"Not Found"}`}, err)
+}